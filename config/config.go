@@ -1,6 +1,59 @@
 package config
 
-import "fmt"
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const DefaultSchemaCacheTTL = 5 * time.Minute
+
+const (
+	DefaultAdminRole = "admin"
+	DefaultRoleClaim = "role"
+
+	DefaultClaimsSetting = "request.jwt.claims"
+
+	DefaultAPIKeyTable  = "genapi_api_keys"
+	DefaultAPIKeyHeader = "X-API-Key"
+
+	DefaultAuditTable = "genapi_audit_log"
+
+	HistoryTableSuffix = "_history"
+
+	DefaultTenantColumn = "tenant_id"
+
+	DefaultOpenAPITitle       = "Generated API"
+	DefaultOpenAPIVersion     = "1.0.0"
+	DefaultSwaggerUIAssetsURL = "https://unpkg.com/swagger-ui-dist@5.17.14"
+
+	DefaultGraphQLMaxDepth  = 10
+	DefaultGraphQLMaxFields = 200
+
+	DefaultGRPCAddress = ":9090"
+
+	DefaultExportMaxRows = 100000
+
+	DefaultXLSXImportMaxBytes = 32 << 20
+)
+
+const (
+	OperationCreate = "create"
+	OperationRead   = "read"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+var (
+	ReadOnlyOperations = []string{OperationRead}
+	NoDeleteOperations = []string{OperationCreate, OperationRead, OperationUpdate}
+)
 
 type GenApiConfig struct {
 	PostgresUrl      string
@@ -8,6 +61,263 @@ type GenApiConfig struct {
 	PostgresPassword string
 	PostgresDB       string
 	Port             string
+	// SchemaCacheTTL controls how long introspected table metadata is reused
+	// before it is read again from information_schema.
+	SchemaCacheTTL time.Duration
+	// ExportMaxRows caps the rows of a CSV or XLSX export, which is not
+	// subject to the page size limit of list requests. NDJSON exports stream
+	// every row. 100000 by default.
+	ExportMaxRows int
+	// CSVRawCells writes CSV export cells as they are. By default text cells
+	// starting with =, +, -, @, a tab or a carriage return, other than
+	// numbers, are prefixed with ' so that spreadsheet applications do not
+	// evaluate them as formulas.
+	CSVRawCells bool
+	// XLSXImportMaxBytes caps the size of an imported workbook, which is read
+	// into memory. Unpacked it may take 16 times as much, worksheets larger
+	// than the cap are unpacked to temporary files. 32 MiB by default.
+	XLSXImportMaxBytes int64
+	// NumericAsString writes numeric columns as JSON strings instead of
+	// numbers, for clients that would parse them into floating point.
+	NumericAsString bool
+	// BigIntAsString writes bigint columns as JSON strings, JavaScript numbers
+	// cannot hold integers above 2^53.
+	BigIntAsString bool
+	// StrictMode rejects request bodies and query parameters that reference
+	// columns which do not exist instead of silently ignoring them.
+	StrictMode bool
+	// AllowedTables, when not empty, limits the exposed tables to this list.
+	AllowedTables []string
+	// DeniedTables are never exposed, even if they appear in AllowedTables.
+	DeniedTables []string
+	// Tables holds per-table overrides keyed by table name.
+	Tables map[string]TableConfig
+	// AdminRole is the principal role allowed to write admin-only columns.
+	AdminRole string
+	// Auth enables authentication of every generated route when set.
+	Auth *AuthConfig
+	// RowLevelSecurity delegates authorization to PostgreSQL when set.
+	RowLevelSecurity *RowLevelSecurityConfig
+	// RateLimit throttles item requests per client when set.
+	RateLimit *RateLimitConfig
+	// Audit records every write in an audit table when set.
+	Audit *AuditConfig
+	// History keeps every version of the listed tables when set.
+	History *HistoryConfig
+	// Tenancy scopes every request to the tenant resolved from it when set.
+	Tenancy *TenancyConfig
+	// OpenAPI serves a generated OpenAPI document when set.
+	OpenAPI *OpenAPIConfig
+	// GraphQL serves a GraphQL endpoint at /graphql when set.
+	GraphQL *GraphQLConfig
+	// GRPC starts a gRPC server next to the HTTP routes when set.
+	GRPC *GRPCConfig
+}
+
+type GRPCConfig struct {
+	// Address is the TCP address the server listens on, ":9090" by default.
+	Address string
+	// TLS serves the calls over TLS. Without it the server listens in
+	// plaintext, which only suits a trusted network or a TLS terminating
+	// proxy in front of it.
+	TLS *tls.Config
+	// Reflection registers the server reflection service so that tools such
+	// as grpcurl can discover the table services.
+	Reflection bool
+}
+
+type GraphQLConfig struct {
+	// MaxDepth limits the nesting of selections, relationship fields make
+	// every level a query. 10 by default.
+	MaxDepth int
+	// MaxFields limits the fields a request selects. Aliases and every use
+	// of a fragment count, so that a request cannot run any number of
+	// queries side by side. 200 by default.
+	MaxFields int
+}
+
+type OpenAPIConfig struct {
+	// Title and Version describe the API in the info section, "Generated
+	// API" and "1.0.0" by default.
+	Title       string
+	Version     string
+	Description string
+	// SwaggerUI serves a Swagger UI page at /docs.
+	SwaggerUI bool
+	// SwaggerUIAssetsURL is the base URL of the swagger-ui-dist assets used by
+	// the page, a pinned release on a public CDN by default.
+	SwaggerUIAssetsURL string
+	// SwaggerUIIntegrity holds the Subresource Integrity hashes the browser
+	// checks the assets against.
+	SwaggerUIIntegrity SwaggerUIIntegrity
+	// SwaggerUIAssets serves the swagger-ui-dist files at /docs/assets
+	// instead of loading them from SwaggerUIAssetsURL, for example an
+	// embedded copy of the package.
+	SwaggerUIAssets fs.FS
+}
+
+// SwaggerUIIntegrity holds Subresource Integrity hashes such as
+// "sha384-...".
+type SwaggerUIIntegrity struct {
+	// CSS is the hash of swagger-ui.css.
+	CSS string
+	// JS is the hash of swagger-ui-bundle.js.
+	JS string
+}
+
+// TenancyConfig resolves the tenant of each request. The first configured
+// source that yields a value wins, in the order Resolver, Claim, Header,
+// Subdomain, except that a configured Claim must be present unless
+// ClaimFallback is set. Requests without a tenant are rejected.
+type TenancyConfig struct {
+	// Column holds the tenant in every shared table, "tenant_id" by default.
+	// Tables without it are not scoped.
+	Column string
+	// Resolver replaces the built-in sources. The principal is available
+	// from the request context.
+	Resolver func(r *http.Request) (string, error)
+	// Claim reads the tenant from a claim of the authenticated principal.
+	Claim string
+	// ClaimFallback lets requests without the claim, including anonymous
+	// ones, fall back to Header and Subdomain. Otherwise they are rejected,
+	// so that a missing claim cannot be replaced by a client supplied value.
+	ClaimFallback bool
+	// Header reads the tenant from a request header. Only use it behind a
+	// gateway that sets the header, clients can send any value.
+	Header string
+	// Subdomain reads the tenant from the first label of the host, as in
+	// "acme" for acme.example.com.
+	Subdomain bool
+	// SchemaPerTenant routes every tenant to its own PostgreSQL schema
+	// instead of scoping shared tables by Column.
+	SchemaPerTenant bool
+	// Schema maps a tenant to its schema, the tenant itself by default.
+	Schema func(tenant string) string
+}
+
+type HistoryConfig struct {
+	// Tables are versioned through "<table>_history" shadow tables that are
+	// maintained by triggers installed on setup.
+	Tables []string
+}
+
+type AuditConfig struct {
+	// Table stores the entries, "genapi_audit_log" by default. It is created
+	// on setup and never exposed through the item routes.
+	Table string
+}
+
+// RateLimit allows Requests per Per, with bursts of up to Requests.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l RateLimit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	ResetAfter time.Duration
+}
+
+// RateLimitStore keeps the token buckets. Implement it on top of a shared
+// backend to enforce limits across several instances.
+type RateLimitStore interface {
+	// Take removes a token from the bucket of key when it holds one.
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+	// Charge removes cost tokens from the bucket of key even when it holds
+	// fewer, later requests are throttled until the debt is refilled.
+	Charge(ctx context.Context, key string, limit RateLimit, cost int) error
+}
+
+// RateLimitConfig configures token buckets tracked per client, table and
+// operation. Clients are identified by API key or token subject, falling back
+// to the IP address. Requests for tables the client cannot reach, and routes
+// without a table, share the bucket of the empty table name.
+type RateLimitConfig struct {
+	Default RateLimit
+	// Tables overrides Default per table and operation. The empty operation
+	// applies to every operation of the table.
+	Tables map[string]map[string]RateLimit
+	// Store defaults to an in-process store.
+	Store RateLimitStore
+}
+
+// RowLevelSecurityConfig makes every request run in a transaction that
+// switches to the principal role and publishes its claims, so that database
+// row-level security policies apply.
+type RowLevelSecurityConfig struct {
+	// ClaimsSetting receives the JSON encoded claims, "request.jwt.claims" by default.
+	ClaimsSetting string
+	// DefaultRole is assumed by principals without a role, Auth.AnonymousRole
+	// when empty. Requests without any role are rejected.
+	DefaultRole string
+}
+
+type AuthConfig struct {
+	// Authenticator replaces the built-in JWT verifier when set. It returns a
+	// nil principal when the request carries no credentials.
+	Authenticator func(r *http.Request) (*domains.Principal, error)
+	// JWT configures the built-in verifier for bearer tokens.
+	JWT *JWTConfig
+	// APIKeys enables scoped API keys stored in PostgreSQL.
+	APIKeys *APIKeyConfig
+	// AnonymousRole is given to requests without credentials. Such requests
+	// are rejected with 401 when it is empty.
+	AnonymousRole string
+}
+
+type APIKeyConfig struct {
+	// Table stores the keys, "genapi_api_keys" by default. It is created on
+	// setup and never exposed through the item routes.
+	Table string
+	// Header carries the key, "X-API-Key" by default.
+	Header string
+	// AdminKey is a static key that authenticates as AdminRole, intended for
+	// managing the stored keys.
+	AdminKey string
+}
+
+type JWTConfig struct {
+	// Secret is the static HS256 key.
+	Secret []byte
+	// PublicKeyFile is a PEM encoded RS256 public key.
+	PublicKeyFile string
+	// JWKSFile is a local JSON Web Key Set with RSA or symmetric keys.
+	JWKSFile string
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew when checking exp, nbf and iat. Tokens
+	// without exp are always rejected.
+	Leeway time.Duration
+	// RoleClaim names the claim holding the principal role, "role" by default.
+	RoleClaim string
+}
+
+type TableConfig struct {
+	// Strict overrides StrictMode for this table when set.
+	Strict *bool
+	// Operations lists the allowed operations. All operations are allowed
+	// when it is empty.
+	Operations []string
+	// Columns holds column policies keyed by column name.
+	Columns map[string]ColumnPolicy
+}
+
+type ColumnPolicy struct {
+	// Hidden columns are never returned, filtered, searched or sorted on,
+	// and writes to them are rejected as writes to unknown columns.
+	Hidden bool
+	// ReadOnly columns cannot be written through the API.
+	ReadOnly bool
+	// WriteOnce columns can be set on create but not changed by updates.
+	WriteOnce bool
+	// AdminOnly columns can only be written by principals with AdminRole.
+	AdminOnly bool
 }
 
 func (c *GenApiConfig) GetConnectionString() string {
@@ -25,5 +335,212 @@ func (c *GenApiConfig) Validate() error {
 	if c.PostgresDB == "" {
 		return fmt.Errorf("postgres database name is required")
 	}
+	if c.History != nil && len(c.History.Tables) > 0 && c.Tenancy != nil && c.Tenancy.SchemaPerTenant {
+		return fmt.Errorf("row history is not supported with a schema per tenant")
+	}
 	return nil
 }
+
+func (c *GenApiConfig) GetSchemaCacheTTL() time.Duration {
+	if c.SchemaCacheTTL <= 0 {
+		return DefaultSchemaCacheTTL
+	}
+	return c.SchemaCacheTTL
+}
+
+func (c *GenApiConfig) GetExportMaxRows() int {
+	if c.ExportMaxRows <= 0 {
+		return DefaultExportMaxRows
+	}
+	return c.ExportMaxRows
+}
+
+func (c *GenApiConfig) GetXLSXImportMaxBytes() int64 {
+	if c.XLSXImportMaxBytes <= 0 {
+		return DefaultXLSXImportMaxBytes
+	}
+	return c.XLSXImportMaxBytes
+}
+
+func (c *GenApiConfig) IsStrict(tableName string) bool {
+	if table, ok := c.Tables[tableName]; ok && table.Strict != nil {
+		return *table.Strict
+	}
+	return c.StrictMode
+}
+
+// IsTableExposed reports whether the table may be reached through the API.
+func (c *GenApiConfig) IsTableExposed(tableName string) bool {
+	if slices.Contains(c.InternalTables(), tableName) || slices.Contains(c.DeniedTables, tableName) {
+		return false
+	}
+	if len(c.AllowedTables) > 0 {
+		return slices.Contains(c.AllowedTables, tableName)
+	}
+	return true
+}
+
+func (c *GenApiConfig) IsOperationAllowed(tableName, operation string) bool {
+	table, ok := c.Tables[tableName]
+	if !ok || len(table.Operations) == 0 {
+		return true
+	}
+	return slices.Contains(table.Operations, operation)
+}
+
+func (c *GenApiConfig) GetColumnPolicy(tableName, column string) ColumnPolicy {
+	return c.Tables[tableName].Columns[column]
+}
+
+func (c *GenApiConfig) IsColumnHidden(tableName, column string) bool {
+	return c.GetColumnPolicy(tableName, column).Hidden
+}
+
+func (c *GenApiConfig) GetAdminRole() string {
+	if c.AdminRole == "" {
+		return DefaultAdminRole
+	}
+	return c.AdminRole
+}
+
+func (c *JWTConfig) GetRoleClaim() string {
+	if c.RoleClaim == "" {
+		return DefaultRoleClaim
+	}
+	return c.RoleClaim
+}
+
+func (c *RowLevelSecurityConfig) GetClaimsSetting() string {
+	if c.ClaimsSetting == "" {
+		return DefaultClaimsSetting
+	}
+	return c.ClaimsSetting
+}
+
+// InternalTables lists the tables owned by the library itself.
+func (c *GenApiConfig) InternalTables() []string {
+	var tables []string
+	if c.Auth != nil && c.Auth.APIKeys != nil {
+		tables = append(tables, c.Auth.APIKeys.GetTable())
+	}
+	if c.Audit != nil {
+		tables = append(tables, c.Audit.GetTable())
+	}
+	if c.History != nil {
+		for _, table := range c.History.Tables {
+			tables = append(tables, HistoryTable(table))
+		}
+	}
+	return tables
+}
+
+// IsVersioned reports whether the history of tableName is recorded.
+func (c *GenApiConfig) IsVersioned(tableName string) bool {
+	return c.History != nil && slices.Contains(c.History.Tables, tableName)
+}
+
+// HistoryTable names the shadow table holding the versions of tableName.
+func HistoryTable(tableName string) string {
+	return tableName + HistoryTableSuffix
+}
+
+func (c *APIKeyConfig) GetTable() string {
+	if c.Table == "" {
+		return DefaultAPIKeyTable
+	}
+	return c.Table
+}
+
+func (c *APIKeyConfig) GetHeader() string {
+	if c.Header == "" {
+		return DefaultAPIKeyHeader
+	}
+	return c.Header
+}
+
+func (c *RateLimitConfig) LimitFor(tableName, operation string) RateLimit {
+	if operations, ok := c.Tables[tableName]; ok {
+		if limit, ok := operations[operation]; ok {
+			return limit
+		}
+		if limit, ok := operations[""]; ok {
+			return limit
+		}
+	}
+	return c.Default
+}
+
+func (c *AuditConfig) GetTable() string {
+	if c.Table == "" {
+		return DefaultAuditTable
+	}
+	return c.Table
+}
+
+// HiddenColumns lists the hidden columns of a table in a stable order.
+func (c *GenApiConfig) HiddenColumns(tableName string) []string {
+	hidden := []string{}
+	for column, policy := range c.Tables[tableName].Columns {
+		if policy.Hidden {
+			hidden = append(hidden, column)
+		}
+	}
+	slices.Sort(hidden)
+	return hidden
+}
+
+func (c *TenancyConfig) GetColumn() string {
+	if c.Column == "" {
+		return DefaultTenantColumn
+	}
+	return c.Column
+}
+
+func (c *TenancyConfig) GetSchema(tenant string) string {
+	if c.Schema == nil {
+		return tenant
+	}
+	return c.Schema(tenant)
+}
+
+func (c *OpenAPIConfig) GetTitle() string {
+	if c.Title == "" {
+		return DefaultOpenAPITitle
+	}
+	return c.Title
+}
+
+func (c *OpenAPIConfig) GetVersion() string {
+	if c.Version == "" {
+		return DefaultOpenAPIVersion
+	}
+	return c.Version
+}
+
+func (c *OpenAPIConfig) GetSwaggerUIAssetsURL() string {
+	if c.SwaggerUIAssetsURL == "" {
+		return DefaultSwaggerUIAssetsURL
+	}
+	return strings.TrimRight(c.SwaggerUIAssetsURL, "/")
+}
+
+func (c *GraphQLConfig) GetMaxDepth() int {
+	if c.MaxDepth <= 0 {
+		return DefaultGraphQLMaxDepth
+	}
+	return c.MaxDepth
+}
+
+func (c *GraphQLConfig) GetMaxFields() int {
+	if c.MaxFields <= 0 {
+		return DefaultGraphQLMaxFields
+	}
+	return c.MaxFields
+}
+
+func (c *GRPCConfig) GetAddress() string {
+	if c.Address == "" {
+		return DefaultGRPCAddress
+	}
+	return c.Address
+}
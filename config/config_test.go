@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     GenApiConfig
+		wantErr bool
+	}{
+		{name: "valid", cfg: GenApiConfig{}},
+		{name: "history", cfg: GenApiConfig{History: &HistoryConfig{Tables: []string{"users"}}}},
+		{name: "history with shared tables", cfg: GenApiConfig{
+			History: &HistoryConfig{Tables: []string{"users"}},
+			Tenancy: &TenancyConfig{Column: "tenant_id"},
+		}},
+		{name: "schema per tenant", cfg: GenApiConfig{Tenancy: &TenancyConfig{SchemaPerTenant: true}}},
+		{name: "history with schema per tenant", cfg: GenApiConfig{
+			History: &HistoryConfig{Tables: []string{"users"}},
+			Tenancy: &TenancyConfig{SchemaPerTenant: true},
+		}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.PostgresUrl, cfg.PostgresUser, cfg.PostgresDB = "localhost:5432", "postgres", "app"
+
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package domains
+
+import "time"
+
+type APIKey struct {
+	ID                int64      `json:"id"`
+	Name              string     `json:"name"`
+	Prefix            string     `json:"prefix"`
+	Role              string     `json:"role,omitempty"`
+	AllowedTables     []string   `json:"allowed_tables"`
+	AllowedOperations []string   `json:"allowed_operations"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	SecretHash        string     `json:"-"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name              string     `json:"name" binding:"required"`
+	Role              string     `json:"role"`
+	AllowedTables     []string   `json:"allowed_tables"`
+	AllowedOperations []string   `json:"allowed_operations"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+}
+
+// CreatedAPIKey is returned once on creation. Key is the only copy of the
+// secret, the database keeps a hash.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,21 @@
+package domains
+
+import "time"
+
+const (
+	AuditOperationCreate = "create"
+	AuditOperationUpdate = "update"
+	AuditOperationDelete = "delete"
+)
+
+type AuditEntry struct {
+	ID         int64          `json:"id"`
+	Actor      *string        `json:"actor"`
+	TableName  string         `json:"table_name"`
+	PrimaryKey string         `json:"primary_key"`
+	Operation  string         `json:"operation"`
+	OldRow     map[string]any `json:"old_row"`
+	NewRow     map[string]any `json:"new_row"`
+	RequestID  *string        `json:"request_id"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
@@ -0,0 +1,85 @@
+package domains
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ErrCodeInternal            = "internal_error"
+	ErrCodeBadRequest          = "bad_request"
+	ErrCodeConflict            = "conflict"
+	ErrCodeInvalidParameter    = "invalid_parameter"
+	ErrCodeValidationFailed    = "validation_failed"
+	ErrCodeUnknownFields       = "unknown_fields"
+	ErrCodeUnauthorized        = "unauthorized"
+	ErrCodeNotFound            = "not_found"
+	ErrCodeOperationNotAllowed = "operation_not_allowed"
+	ErrCodeUniqueViolation     = "unique_violation"
+	ErrCodeForeignKeyViolation = "foreign_key_violation"
+	ErrCodeRowReferenced       = "row_referenced"
+	ErrCodeNotNullViolation    = "not_null_violation"
+	ErrCodeCheckViolation      = "check_violation"
+	ErrCodeInvalidInput        = "invalid_input"
+	ErrCodePermissionDenied    = "permission_denied"
+	ErrCodeQueryTimeout        = "query_timeout"
+	ErrCodeRateLimited         = "rate_limited"
+)
+
+// Error is a classified failure that handlers translate into an HTTP status
+// and a machine-readable code.
+type Error struct {
+	Code       string
+	Message    string
+	Table      string
+	Constraint string
+	Column     string
+	Err        error
+}
+
+func NewError(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil && e.Message == "" {
+		return e.Err.Error()
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field.Field, field.Message))
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+type UnknownFieldsError struct {
+	Fields []FieldError
+}
+
+func (e *UnknownFieldsError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		names = append(names, field.Field)
+	}
+	return "unknown fields: " + strings.Join(names, ", ")
+}
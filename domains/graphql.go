@@ -0,0 +1,8 @@
+package domains
+
+// GraphQLRequest is the body of a GraphQL request.
+type GraphQLRequest struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
@@ -0,0 +1,13 @@
+package domains
+
+import "time"
+
+// RowVersion is one state of a row in a versioned table. ValidTo is nil for
+// the current version.
+type RowVersion struct {
+	VersionID int64          `json:"version_id"`
+	Operation string         `json:"operation"`
+	ValidFrom time.Time      `json:"valid_from"`
+	ValidTo   *time.Time     `json:"valid_to"`
+	Data      map[string]any `json:"data"`
+}
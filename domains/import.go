@@ -0,0 +1,47 @@
+package domains
+
+import "fmt"
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+	// ImportFormatXLSX sources give the raw cell values, dates are serial
+	// numbers.
+	ImportFormatXLSX = "xlsx"
+)
+
+// ImportSource yields the rows of a bulk import.
+type ImportSource interface {
+	// Columns returns the column names given by the header, or by the first
+	// record for formats without one.
+	Columns() ([]string, error)
+	// Next returns the values of the next row in the order of Columns, or
+	// io.EOF after the last row. An *InvalidRowError rejects only that row.
+	Next() ([]any, error)
+	// Format is one of the ImportFormat constants. Values of CSV and XLSX
+	// sources are cell texts that are parsed according to the column types.
+	Format() string
+}
+
+// InvalidRowError reports a row of an import that could not be read.
+type InvalidRowError struct {
+	Message string
+}
+
+func (e *InvalidRowError) Error() string {
+	return e.Message
+}
+
+type ImportResult struct {
+	Rows   int64 `json:"rows"`
+	DryRun bool  `json:"dry_run"`
+}
+
+// ImportField names a column of an imported row in field errors, or the row
+// itself when column is empty. Rows are numbered from 1.
+func ImportField(row int, column string) string {
+	if column == "" {
+		return fmt.Sprintf("rows[%d]", row)
+	}
+	return fmt.Sprintf("rows[%d].%s", row, column)
+}
@@ -27,6 +27,8 @@ type ItemFilter struct {
 	Sort    string         `json:"sort" form:"sort"`
 	Filters map[string]any `json:"filters" form:"filters"`
 	Search  string         `json:"search" form:"search"`
+	// AsOf reads the state of a versioned table at the given time.
+	AsOf *time.Time `json:"as_of" form:"as_of"`
 }
 
 type ItemResponse struct {
@@ -46,23 +48,78 @@ type ItemsListResponse struct {
 	Error   string           `json:"error,omitempty"`
 }
 
-type ErrorResponse struct {
+type DataResponse struct {
 	Success bool   `json:"success"`
-	Error   string `json:"error"`
+	Data    any    `json:"data"`
 	Message string `json:"message,omitempty"`
 }
 
+type ErrorResponse struct {
+	Success    bool         `json:"success"`
+	Code       string       `json:"code,omitempty"`
+	Error      string       `json:"error"`
+	Message    string       `json:"message,omitempty"`
+	Constraint string       `json:"constraint,omitempty"`
+	Column     string       `json:"column,omitempty"`
+	Fields     []FieldError `json:"fields,omitempty"`
+}
+
 type DatabaseColumn struct {
-	Name         string `db:"column_name"`
-	DataType     string `db:"data_type"`
-	IsNullable   string `db:"is_nullable"`
-	DefaultValue string `db:"column_default"`
+	Name             string   `json:"name" db:"column_name"`
+	Position         int      `json:"position" db:"ordinal_position"`
+	DataType         string   `json:"data_type" db:"data_type"`
+	IsNullable       bool     `json:"is_nullable" db:"is_nullable"`
+	DefaultValue     *string  `json:"default_value,omitempty" db:"column_default"`
+	HasDefault       bool     `json:"has_default"`
+	IsGenerated      bool     `json:"is_generated"`
+	MaxLength        *int     `json:"max_length,omitempty" db:"character_maximum_length"`
+	NumericPrecision *int     `json:"numeric_precision,omitempty" db:"numeric_precision"`
+	NumericScale     *int     `json:"numeric_scale,omitempty" db:"numeric_scale"`
+	EnumValues       []string `json:"enum_values,omitempty"`
+	Comment          *string  `json:"comment,omitempty"`
 }
 
 type TableInfo struct {
-	Name       string           `json:"name"`
-	Columns    []DatabaseColumn `json:"columns"`
-	PrimaryKey string           `json:"primary_key"`
+	Name             string            `json:"name"`
+	Comment          *string           `json:"comment,omitempty"`
+	Columns          []DatabaseColumn  `json:"columns"`
+	PrimaryKey       string            `json:"primary_key"`
+	ForeignKeys      []ForeignKey      `json:"foreign_keys"`
+	CheckConstraints []CheckConstraint `json:"check_constraints"`
+	Indexes          []Index           `json:"indexes"`
+}
+
+// TableSummary is the listing entry of a table. EstimatedRows comes from the
+// planner statistics and is nil for views and tables never analyzed.
+type TableSummary struct {
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	EstimatedRows *int64  `json:"estimated_rows"`
+	Comment       *string `json:"comment,omitempty"`
+}
+
+type ForeignKey struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+}
+
+type CheckConstraint struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	Expression string   `json:"expression"`
+}
+
+// Index describes an index of a table. Columns holds the key columns in
+// order, expression keys are reported as their SQL text.
+type Index struct {
+	Name      string   `json:"name"`
+	Columns   []string `json:"columns"`
+	IsUnique  bool     `json:"is_unique"`
+	IsPrimary bool     `json:"is_primary"`
+	Method    string   `json:"method"`
+	Predicate *string  `json:"predicate,omitempty"`
 }
 
 type TimeFields struct {
@@ -70,3 +127,18 @@ type TimeFields struct {
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
+
+// RowWriter receives the rows of a streamed list as they are read.
+type RowWriter interface {
+	// WriteColumns is called once with the columns, before any row.
+	WriteColumns(columns []DatabaseColumn) error
+	WriteRow(row map[string]any) error
+}
+
+// CappedRowWriter is a RowWriter whose exports are capped, such as one that
+// holds the rows in memory until they are all written.
+type CappedRowWriter interface {
+	RowWriter
+	// Truncated is called when more rows matched than were written.
+	Truncated()
+}
@@ -0,0 +1,43 @@
+package domains
+
+import (
+	"context"
+	"slices"
+)
+
+type principalContextKey struct{}
+
+// Principal identifies the caller of a request.
+type Principal struct {
+	Subject string
+	Role    string
+	Claims  map[string]any
+	// AllowedTables and AllowedOperations restrict scoped credentials such as
+	// API keys. Empty lists mean no restriction.
+	AllowedTables     []string
+	AllowedOperations []string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && role != "" && p.Role == role
+}
+
+func (p *Principal) CanAccessTable(tableName string) bool {
+	return p == nil || len(p.AllowedTables) == 0 || slices.Contains(p.AllowedTables, tableName)
+}
+
+func (p *Principal) CanPerform(operation string) bool {
+	return p == nil || len(p.AllowedOperations) == 0 || slices.Contains(p.AllowedOperations, operation)
+}
+
+// ContextWithPrincipal attaches the principal to ctx. Middleware registered in
+// front of the generated routes uses it to identify callers.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of the request or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
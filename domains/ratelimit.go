@@ -0,0 +1,28 @@
+package domains
+
+import "context"
+
+type rateLimiterContextKey struct{}
+
+// RateLimiter charges the work of a request to the rate limits of its client.
+// The rate limiting middleware attaches one to the request context.
+type RateLimiter interface {
+	// Take takes a token for operation on tableName and returns a
+	// rate_limited error when the bucket is empty.
+	Take(ctx context.Context, tableName, operation string) error
+	// Charge takes cost more tokens for work whose size is only known once it
+	// is done, such as the rows of an import. It may leave the bucket in debt
+	// that later requests wait for.
+	Charge(ctx context.Context, tableName, operation string, cost int)
+}
+
+func ContextWithRateLimiter(ctx context.Context, limiter RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterContextKey{}, limiter)
+}
+
+// RateLimiterFromContext returns the rate limiter of the request or nil when
+// rate limiting is not configured.
+func RateLimiterFromContext(ctx context.Context) RateLimiter {
+	limiter, _ := ctx.Value(rateLimiterContextKey{}).(RateLimiter)
+	return limiter
+}
@@ -0,0 +1,32 @@
+package domains
+
+import "context"
+
+type tenantContextKey struct{}
+
+type schemaContextKey struct{}
+
+// TenantScope restricts a table to the rows of a single tenant.
+type TenantScope struct {
+	Column string
+	Value  string
+}
+
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// ContextWithSchema routes the request to a tenant schema.
+func ContextWithSchema(ctx context.Context, schema string) context.Context {
+	return context.WithValue(ctx, schemaContextKey{}, schema)
+}
+
+func SchemaFromContext(ctx context.Context) string {
+	schema, _ := ctx.Value(schemaContextKey{}).(string)
+	return schema
+}
@@ -0,0 +1,221 @@
+package grpcserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"math"
+	"strconv"
+)
+
+// rowConverter converts between rows of a table and its Row messages.
+type rowConverter struct {
+	columns    map[string]domains.DatabaseColumn
+	descriptor protoreflect.MessageDescriptor
+}
+
+func newRowConverter(schema *domains.TableInfo, row protoreflect.MessageDescriptor) rowConverter {
+	columns := make(map[string]domains.DatabaseColumn, len(schema.Columns))
+	for _, column := range schema.Columns {
+		columns[column.Name] = column
+	}
+	return rowConverter{columns: columns, descriptor: row}
+}
+
+// message converts a row to a Row message. NULL columns, and NULL elements of
+// arrays, are left unset.
+func (c rowConverter) message(row map[string]any) (protoreflect.Message, error) {
+	message := dynamicpb.NewMessage(c.descriptor)
+	fields := c.descriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		value := row[string(field.Name())]
+		if value == nil {
+			continue
+		}
+
+		if field.IsList() {
+			elements, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("column %s: expected an array, got %T", field.Name(), value)
+			}
+			list := message.Mutable(field).List()
+			for _, element := range elements {
+				if element == nil {
+					continue
+				}
+				converted, err := c.protoValue(field, element)
+				if err != nil {
+					return nil, err
+				}
+				list.Append(converted)
+			}
+			continue
+		}
+
+		converted, err := c.protoValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		message.Set(field, converted)
+	}
+	return message, nil
+}
+
+func (c rowConverter) protoValue(field protoreflect.FieldDescriptor, value any) (protoreflect.Value, error) {
+	switch field.Kind() {
+	case protoreflect.Int32Kind:
+		number, ok := toInteger(value)
+		if !ok || number < math.MinInt32 || number > math.MaxInt32 {
+			return protoreflect.Value{}, fmt.Errorf("column %s: %v is not a 32 bit integer", field.Name(), value)
+		}
+		return protoreflect.ValueOfInt32(int32(number)), nil
+	case protoreflect.Int64Kind:
+		number, ok := toInteger(value)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("column %s: %v is not an integer", field.Name(), value)
+		}
+		return protoreflect.ValueOfInt64(number), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		number, ok := value.(float64)
+		if !ok {
+			parsed, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+			if err != nil {
+				return protoreflect.Value{}, fmt.Errorf("column %s: %v is not a number", field.Name(), value)
+			}
+			number = parsed
+		}
+		if field.Kind() == protoreflect.FloatKind {
+			return protoreflect.ValueOfFloat32(float32(number)), nil
+		}
+		return protoreflect.ValueOfFloat64(number), nil
+	case protoreflect.BoolKind:
+		boolean, ok := value.(bool)
+		if !ok {
+			parsed, err := strconv.ParseBool(fmt.Sprint(value))
+			if err != nil {
+				return protoreflect.Value{}, fmt.Errorf("column %s: %v is not a boolean", field.Name(), value)
+			}
+			boolean = parsed
+		}
+		return protoreflect.ValueOfBool(boolean), nil
+	}
+
+	if dataType := c.columns[string(field.Name())].DataType; dataType == "json" || dataType == "jsonb" {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("column %s: %w", field.Name(), err)
+		}
+		return protoreflect.ValueOfString(string(encoded)), nil
+	}
+	switch v := value.(type) {
+	case string:
+		return protoreflect.ValueOfString(v), nil
+	case float64:
+		return protoreflect.ValueOfString(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	default:
+		return protoreflect.ValueOfString(fmt.Sprint(v)), nil
+	}
+}
+
+// row converts the fields set on a Row message to the payload of a create or
+// update request.
+func (c rowConverter) row(message protoreflect.Message) (map[string]any, error) {
+	row := map[string]any{}
+	var err error
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		name := string(field.Name())
+		if !field.IsList() {
+			row[name], err = c.goValue(field, value)
+			return err == nil
+		}
+
+		list := value.List()
+		elements := make([]any, list.Len())
+		for i := range elements {
+			if elements[i], err = c.goValue(field, list.Get(i)); err != nil {
+				return false
+			}
+		}
+		row[name] = elements
+		return true
+	})
+	return row, err
+}
+
+func (c rowConverter) goValue(field protoreflect.FieldDescriptor, value protoreflect.Value) (any, error) {
+	switch field.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Int64Kind:
+		return value.Int(), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return value.Float(), nil
+	case protoreflect.BoolKind:
+		return value.Bool(), nil
+	}
+
+	text := value.String()
+	if dataType := c.columns[string(field.Name())].DataType; dataType == "json" || dataType == "jsonb" {
+		var decoded any
+		if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+			return nil, &domains.ValidationError{Fields: []domains.FieldError{{Field: string(field.Name()), Message: "must be valid JSON"}}}
+		}
+		return decoded, nil
+	}
+	return text, nil
+}
+
+// itemFilter converts a ListRequest to the filter of the list operation.
+// Filter values are parsed the same way as query parameters.
+func itemFilter(request protoreflect.Message) *domains.ItemFilter {
+	fields := request.Descriptor().Fields()
+	filter := &domains.ItemFilter{
+		Limit:   int(request.Get(fields.ByName("limit")).Int()),
+		Offset:  int(request.Get(fields.ByName("offset")).Int()),
+		OrderBy: request.Get(fields.ByName("order_by")).String(),
+		Sort:    request.Get(fields.ByName("sort")).String(),
+		Search:  request.Get(fields.ByName("search")).String(),
+		Filters: map[string]any{},
+	}
+
+	filters := request.Get(fields.ByName("filters")).List()
+	for i := 0; i < filters.Len(); i++ {
+		entry := filters.Get(i).Message()
+		entryFields := entry.Descriptor().Fields()
+		column := entry.Get(entryFields.ByName("column")).String()
+		values := entry.Get(entryFields.ByName("values")).List()
+
+		switch values.Len() {
+		case 0:
+		case 1:
+			filter.Filters[column] = utils.ParseValue(values.Get(0).String())
+		default:
+			parsed := make([]any, values.Len())
+			for j := range parsed {
+				parsed[j] = utils.ParseValue(values.Get(j).String())
+			}
+			filter.Filters[column] = parsed
+		}
+	}
+	return filter
+}
+
+func toInteger(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case float64:
+		return int64(v), v == math.Trunc(v)
+	case string:
+		number, err := strconv.ParseInt(v, 10, 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,179 @@
+package grpcserver
+
+import (
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"regexp"
+	"strings"
+)
+
+const (
+	packagePrefix = "genapi"
+	serviceName   = "ItemService"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// tableFilePath is the path of the proto file describing a table.
+func tableFilePath(tableName string) string {
+	return fmt.Sprintf("%s/%s.proto", packagePrefix, tableName)
+}
+
+// tableServiceName is the full name of the service of a table.
+func tableServiceName(tableName string) string {
+	return fmt.Sprintf("%s.%s.%s", packagePrefix, tableName, serviceName)
+}
+
+// tableFromFullName returns the table of a name in a table package, such as
+// "genapi.users.Row" or "genapi.users.ItemService.Get".
+func tableFromFullName(name string) (string, bool) {
+	rest, found := strings.CutPrefix(name, packagePrefix+".")
+	if !found {
+		return "", false
+	}
+	tableName, _, _ := strings.Cut(rest, ".")
+	return tableName, tableName != ""
+}
+
+// tableFile builds the proto file of a table:
+//
+//	package genapi.<table>;
+//
+//	service ItemService {
+//	  rpc Create(CreateRequest) returns (CreateResponse);
+//	  rpc Get(GetRequest) returns (Row);
+//	  rpc List(ListRequest) returns (ListResponse);
+//	  rpc Update(UpdateRequest) returns (Row);
+//	  rpc Delete(DeleteRequest) returns (DeleteResponse);
+//	}
+//
+// Row holds a field per column, numbered by the column position so that
+// numbers stay stable when columns are added or dropped. The file uses proto2
+// syntax so that unset fields can be told apart from zero values.
+func tableFile(schema *domains.TableInfo) (protoreflect.FileDescriptor, error) {
+	var rowFields []*descriptorpb.FieldDescriptorProto
+	for _, column := range schema.Columns {
+		if !identifierPattern.MatchString(column.Name) {
+			continue
+		}
+		rowFields = append(rowFields, columnField(column))
+	}
+
+	typeName := func(name string) string {
+		return fmt.Sprintf(".%s.%s.%s", packagePrefix, schema.Name, name)
+	}
+	method := func(name, input, output string) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       stringPtr(name),
+			InputType:  stringPtr(typeName(input)),
+			OutputType: stringPtr(typeName(output)),
+		}
+	}
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    stringPtr(tableFilePath(schema.Name)),
+		Package: stringPtr(packagePrefix + "." + schema.Name),
+		Syntax:  stringPtr("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: stringPtr("Row"), Field: rowFields},
+			{Name: stringPtr("CreateRequest"), Field: []*descriptorpb.FieldDescriptorProto{messageField("rows", 1, typeName("Row"), true)}},
+			{Name: stringPtr("CreateResponse"), Field: []*descriptorpb.FieldDescriptorProto{messageField("rows", 1, typeName("Row"), true)}},
+			{Name: stringPtr("GetRequest"), Field: []*descriptorpb.FieldDescriptorProto{scalarField("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)}},
+			{Name: stringPtr("Filter"), Field: []*descriptorpb.FieldDescriptorProto{
+				scalarField("column", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				repeatedField(scalarField("values", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+			}},
+			{Name: stringPtr("ListRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				scalarField("limit", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				scalarField("offset", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				scalarField("order_by", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				scalarField("sort", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				scalarField("search", 5, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				messageField("filters", 6, typeName("Filter"), true),
+			}},
+			{Name: stringPtr("ListResponse"), Field: []*descriptorpb.FieldDescriptorProto{
+				messageField("rows", 1, typeName("Row"), true),
+				scalarField("total", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+				scalarField("limit", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				scalarField("offset", 4, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+			}},
+			{Name: stringPtr("UpdateRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				scalarField("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				messageField("row", 2, typeName("Row"), false),
+				repeatedField(scalarField("null_columns", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+			}},
+			{Name: stringPtr("DeleteRequest"), Field: []*descriptorpb.FieldDescriptorProto{scalarField("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)}},
+			{Name: stringPtr("DeleteResponse")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: stringPtr(serviceName),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Create", "CreateRequest", "CreateResponse"),
+				method("Get", "GetRequest", "Row"),
+				method("List", "ListRequest", "ListResponse"),
+				method("Update", "UpdateRequest", "Row"),
+				method("Delete", "DeleteRequest", "DeleteResponse"),
+			},
+		}},
+	}
+
+	descriptor, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptor of table %s: %w", schema.Name, err)
+	}
+	return descriptor, nil
+}
+
+// columnField maps a column to a Row field. Types without an exact protobuf
+// counterpart, such as numeric, json or timestamps, are carried as strings.
+func columnField(column domains.DatabaseColumn) *descriptorpb.FieldDescriptorProto {
+	fieldType := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	switch strings.TrimSuffix(column.DataType, "[]") {
+	case "smallint", "int2", "integer", "int4":
+		fieldType = descriptorpb.FieldDescriptorProto_TYPE_INT32
+	case "bigint", "int8":
+		fieldType = descriptorpb.FieldDescriptorProto_TYPE_INT64
+	case "real", "float4":
+		fieldType = descriptorpb.FieldDescriptorProto_TYPE_FLOAT
+	case "double precision", "float8":
+		fieldType = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+	case "boolean", "bool":
+		fieldType = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	}
+
+	field := scalarField(column.Name, int32(column.Position), fieldType)
+	if strings.HasSuffix(column.DataType, "[]") {
+		return repeatedField(field)
+	}
+	return field
+}
+
+func scalarField(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   stringPtr(name),
+		Number: &number,
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   fieldType.Enum(),
+	}
+}
+
+func messageField(name string, number int32, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+	field := scalarField(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+	field.TypeName = stringPtr(typeName)
+	if repeated {
+		return repeatedField(field)
+	}
+	return field
+}
+
+func repeatedField(field *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+	field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	return field
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
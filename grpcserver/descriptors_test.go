@@ -0,0 +1,84 @@
+package grpcserver
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"testing"
+)
+
+func TestTableFile(t *testing.T) {
+	schema := &domains.TableInfo{
+		Name: "users",
+		Columns: []domains.DatabaseColumn{
+			{Name: "id", Position: 1, DataType: "integer"},
+			{Name: "balance", Position: 2, DataType: "numeric"},
+			{Name: "visits", Position: 4, DataType: "bigint"},
+			{Name: "tags", Position: 5, DataType: "text[]"},
+			{Name: "active", Position: 6, DataType: "boolean"},
+			{Name: "score", Position: 7, DataType: "double precision"},
+			{Name: "bad-name", Position: 8, DataType: "text"},
+		},
+	}
+
+	file, err := tableFile(schema)
+	if err != nil {
+		t.Fatalf("tableFile() = %v", err)
+	}
+	if file.Path() != "genapi/users.proto" || file.Package() != "genapi.users" {
+		t.Fatalf("tableFile() path = %s, package = %s", file.Path(), file.Package())
+	}
+
+	row := file.Messages().ByName("Row")
+	tests := []struct {
+		name     protoreflect.Name
+		number   protoreflect.FieldNumber
+		kind     protoreflect.Kind
+		repeated bool
+	}{
+		{name: "id", number: 1, kind: protoreflect.Int32Kind},
+		{name: "balance", number: 2, kind: protoreflect.StringKind},
+		{name: "visits", number: 4, kind: protoreflect.Int64Kind},
+		{name: "tags", number: 5, kind: protoreflect.StringKind, repeated: true},
+		{name: "active", number: 6, kind: protoreflect.BoolKind},
+		{name: "score", number: 7, kind: protoreflect.DoubleKind},
+	}
+	for _, tt := range tests {
+		field := row.Fields().ByName(tt.name)
+		if field == nil {
+			t.Errorf("Row has no field %s", tt.name)
+			continue
+		}
+		if field.Number() != tt.number || field.Kind() != tt.kind || field.IsList() != tt.repeated {
+			t.Errorf("field %s = %d %s list=%v, want %d %s list=%v", tt.name, field.Number(), field.Kind(), field.IsList(), tt.number, tt.kind, tt.repeated)
+		}
+	}
+	if row.Fields().Len() != len(tests) {
+		t.Errorf("Row has %d fields, want %d without the invalid identifier", row.Fields().Len(), len(tests))
+	}
+
+	methods := file.Services().ByName("ItemService").Methods()
+	for name := range methodOperations {
+		if methods.ByName(protoreflect.Name(name)) == nil {
+			t.Errorf("ItemService has no method %s", name)
+		}
+	}
+}
+
+func TestTableFromFullName(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		ok    bool
+	}{
+		{name: "genapi.users.ItemService", table: "users", ok: true},
+		{name: "genapi.users.Row", table: "users", ok: true},
+		{name: "genapi.", ok: false},
+		{name: "grpc.reflection.v1.ServerReflection", ok: false},
+	}
+	for _, tt := range tests {
+		table, ok := tableFromFullName(tt.name)
+		if table != tt.table || ok != tt.ok {
+			t.Errorf("tableFromFullName(%q) = %q, %v, want %q, %v", tt.name, table, ok, tt.table, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package grpcserver
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const errorDomain = "go-gen-apis"
+
+var statusCodes = map[string]codes.Code{
+	domains.ErrCodeBadRequest:          codes.InvalidArgument,
+	domains.ErrCodeInvalidParameter:    codes.InvalidArgument,
+	domains.ErrCodeUnknownFields:       codes.InvalidArgument,
+	domains.ErrCodeInvalidInput:        codes.InvalidArgument,
+	domains.ErrCodeValidationFailed:    codes.InvalidArgument,
+	domains.ErrCodeNotNullViolation:    codes.InvalidArgument,
+	domains.ErrCodeCheckViolation:      codes.InvalidArgument,
+	domains.ErrCodeForeignKeyViolation: codes.FailedPrecondition,
+	domains.ErrCodeRowReferenced:       codes.FailedPrecondition,
+	domains.ErrCodeConflict:            codes.Aborted,
+	domains.ErrCodeUniqueViolation:     codes.AlreadyExists,
+	domains.ErrCodeUnauthorized:        codes.Unauthenticated,
+	domains.ErrCodePermissionDenied:    codes.PermissionDenied,
+	domains.ErrCodeNotFound:            codes.NotFound,
+	domains.ErrCodeOperationNotAllowed: codes.Unimplemented,
+	domains.ErrCodeQueryTimeout:        codes.DeadlineExceeded,
+	domains.ErrCodeRateLimited:         codes.ResourceExhausted,
+	domains.ErrCodeInternal:            codes.Internal,
+}
+
+// statusError converts a service error to a gRPC status. The error code is
+// reported in an ErrorInfo detail and field errors in a BadRequest detail.
+func statusError(err error) error {
+	response := utils.ClassifyError(err, "")
+
+	code, ok := statusCodes[response.Code]
+	if !ok {
+		code = codes.Internal
+	}
+
+	info := &errdetails.ErrorInfo{Reason: response.Code, Domain: errorDomain, Metadata: map[string]string{}}
+	if response.Constraint != "" {
+		info.Metadata["constraint"] = response.Constraint
+	}
+	if response.Column != "" {
+		info.Metadata["column"] = response.Column
+	}
+	details := []protoadapt.MessageV1{info}
+
+	if len(response.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range response.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	st := status.New(code, response.Message)
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,420 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/middleware"
+	"github.com/abdulaziz-go/go-gen-apis/service"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Server serves the tables over gRPC. Every table gets a
+// genapi.<table>.ItemService service whose descriptors are built from the
+// schema cache when a call arrives, so schema changes are picked up like on
+// the HTTP routes.
+type Server struct {
+	grpc     *grpc.Server
+	items    *service.ItemService
+	metadata *service.MetadataService
+}
+
+// methodOperations maps the methods of the table services to the operations
+// they are rate limited as.
+var methodOperations = map[string]string{
+	"Create": config.OperationCreate,
+	"Get":    config.OperationRead,
+	"List":   config.OperationRead,
+	"Update": config.OperationUpdate,
+	"Delete": config.OperationDelete,
+}
+
+// NewServer builds the gRPC server. authenticate, resolveTenant and
+// rateLimiter may be nil when authentication, tenancy or rate limiting is not
+// configured. The server uses TLS when cfg.GRPC.TLS is set.
+func NewServer(cfg *config.GenApiConfig, items *service.ItemService, metadata *service.MetadataService,
+	authenticate middleware.RequestAuthenticator, resolveTenant middleware.TenantResolver, rateLimiter *middleware.RateLimiter) *Server {
+	s := &Server{items: items, metadata: metadata}
+	options := []grpc.ServerOption{
+		grpc.UnknownServiceHandler(s.handleCall),
+		grpc.StreamInterceptor(requestInterceptor(authenticate, resolveTenant, rateLimiter)),
+	}
+	if cfg.GRPC.TLS != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(cfg.GRPC.TLS)))
+	}
+	s.grpc = grpc.NewServer(options...)
+
+	if cfg.GRPC.Reflection {
+		reflectionv1.RegisterServerReflectionServer(s.grpc, reflectionServer{server: s})
+		reflectionv1alpha.RegisterServerReflectionServer(s.grpc, reflectionServerV1Alpha{server: s})
+	}
+	return s
+}
+
+// Serve accepts connections on listener until Stop or Shutdown is called.
+func (s *Server) Serve(listener net.Listener) error {
+	return s.grpc.Serve(listener)
+}
+
+// Stop stops the server after the pending calls complete.
+func (s *Server) Stop() {
+	s.grpc.GracefulStop()
+}
+
+// Shutdown stops accepting calls and waits for the pending ones until ctx is
+// done, then closes the remaining connections.
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		<-stopped
+		return ctx.Err()
+	}
+}
+
+// serviceInfo lists the registered services and a service per table the
+// caller of a reflection stream can reach.
+func (s *Server) serviceInfo(ctx context.Context) map[string]grpc.ServiceInfo {
+	services := s.grpc.GetServiceInfo()
+
+	tables, err := s.metadata.ListTables(ctx)
+	if err != nil {
+		logrus.Errorf("grpc: failed to list tables for reflection: %v", err)
+		return services
+	}
+	for _, table := range tables {
+		services[tableServiceName(table.Name)] = grpc.ServiceInfo{Metadata: tableFilePath(table.Name)}
+	}
+	return services
+}
+
+func (s *Server) tableFile(ctx context.Context, tableName string) (*domains.TableInfo, protoreflect.FileDescriptor, error) {
+	schema, err := s.metadata.TableSchema(ctx, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := tableFile(schema)
+	if err != nil {
+		logrus.Errorf("grpc: %v", err)
+		return nil, nil, err
+	}
+	return schema, file, nil
+}
+
+// handleCall serves the unary calls of the table services.
+func (s *Server) handleCall(_ any, stream grpc.ServerStream) error {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	serviceFullName, methodName, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	tableName, ok := tableFromFullName(serviceFullName)
+	if !ok || serviceFullName != tableServiceName(tableName) {
+		return status.Errorf(codes.Unimplemented, "unknown service %s", serviceFullName)
+	}
+	operation, ok := methodOperations[methodName]
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+	}
+
+	ctx := stream.Context()
+	if limiter := domains.RateLimiterFromContext(ctx); limiter != nil {
+		if err := limiter.Take(ctx, tableName, operation); err != nil {
+			return statusError(err)
+		}
+	}
+
+	schema, file, err := s.tableFile(ctx, tableName)
+	if err != nil {
+		return statusError(err)
+	}
+	method := file.Services().Get(0).Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+	}
+
+	request := dynamicpb.NewMessage(method.Input())
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+
+	converter := newRowConverter(schema, file.Messages().ByName("Row"))
+	response, err := s.call(ctx, tableName, method, converter, request)
+	if err != nil {
+		logrus.Errorf("grpc: %s failed: %v", fullMethod, err)
+		return statusError(err)
+	}
+	return stream.SendMsg(response)
+}
+
+func (s *Server) call(ctx context.Context, tableName string, method protoreflect.MethodDescriptor, converter rowConverter, request protoreflect.Message) (protoreflect.Message, error) {
+	response := dynamicpb.NewMessage(method.Output())
+	fields := request.Descriptor().Fields()
+
+	switch method.Name() {
+	case "Create":
+		rows := request.Get(fields.ByName("rows")).List()
+		req := &domains.CreateItemRequest{}
+		for i := 0; i < rows.Len(); i++ {
+			row, err := converter.row(rows.Get(i).Message())
+			if err != nil {
+				return nil, err
+			}
+			req.Data = append(req.Data, row)
+		}
+
+		items, err := s.items.CreateItem(ctx, tableName, req)
+		if err != nil {
+			return nil, err
+		}
+		return response, appendRows(response, converter, items)
+
+	case "Get":
+		item, err := s.items.GetSingleItem(ctx, tableName, request.Get(fields.ByName("id")).String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		return converter.message(item)
+
+	case "List":
+		filter := itemFilter(request)
+		items, total, err := s.items.GetItems(ctx, tableName, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		responseFields := response.Descriptor().Fields()
+		response.Set(responseFields.ByName("total"), protoreflect.ValueOfInt64(int64(total)))
+		response.Set(responseFields.ByName("limit"), protoreflect.ValueOfInt32(int32(filter.Limit)))
+		response.Set(responseFields.ByName("offset"), protoreflect.ValueOfInt32(int32(filter.Offset)))
+		return response, appendRows(response, converter, items)
+
+	case "Update":
+		data, err := converter.row(request.Get(fields.ByName("row")).Message())
+		if err != nil {
+			return nil, err
+		}
+		nullColumns := request.Get(fields.ByName("null_columns")).List()
+		for i := 0; i < nullColumns.Len(); i++ {
+			data[nullColumns.Get(i).String()] = nil
+		}
+
+		item, err := s.items.UpdateItem(ctx, tableName, request.Get(fields.ByName("id")).String(), &domains.UpdateItemRequest{Data: data})
+		if err != nil {
+			return nil, err
+		}
+		return converter.message(item)
+
+	case "Delete":
+		if err := s.items.DeleteItem(ctx, tableName, request.Get(fields.ByName("id")).String()); err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+
+	return nil, status.Errorf(codes.Unimplemented, "unknown method %s", method.FullName())
+}
+
+func appendRows(response *dynamicpb.Message, converter rowConverter, items []map[string]any) error {
+	rows := response.Mutable(response.Descriptor().Fields().ByName("rows")).List()
+	for _, item := range items {
+		row, err := converter.message(item)
+		if err != nil {
+			return err
+		}
+		rows.Append(protoreflect.ValueOfMessage(row))
+	}
+	return nil
+}
+
+// requestInterceptor authenticates calls, scopes them to their tenant and
+// attaches the rate limiter of the client like the HTTP middleware, reading
+// the headers from the call metadata. Calls of the table services take their
+// token once the table is known, reflection streams take one of the shared
+// bucket.
+func requestInterceptor(authenticate middleware.RequestAuthenticator, resolveTenant middleware.TenantResolver, rateLimiter *middleware.RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		r := httpRequest(stream.Context(), info.FullMethod)
+		ctx, requestID := middleware.RequestContext(r)
+		if err := stream.SetHeader(metadata.Pairs(strings.ToLower(middleware.RequestIDHeader), requestID)); err != nil {
+			logrus.Warnf("grpc: failed to set request id header: %v", err)
+		}
+
+		if authenticate != nil {
+			principal, err := authenticate(r.WithContext(ctx))
+			if err != nil {
+				logrus.Warnf("grpc: authentication failed: %v", err)
+				return status.Error(codes.Unauthenticated, err.Error())
+			}
+			ctx = domains.ContextWithPrincipal(ctx, principal)
+		}
+
+		if resolveTenant != nil {
+			tenantCtx, err := resolveTenant(r.WithContext(ctx))
+			if err != nil {
+				var tenantErr *middleware.TenantError
+				if errors.As(err, &tenantErr) {
+					return status.Error(codes.InvalidArgument, tenantErr.Message)
+				}
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			ctx = tenantCtx
+		}
+
+		if rateLimiter != nil {
+			limiter := rateLimiter.ForClient(middleware.ClientKey(ctx, peerIP(ctx)))
+			ctx = domains.ContextWithRateLimiter(ctx, limiter)
+			if strings.HasPrefix(info.FullMethod, "/grpc.reflection.") {
+				if err := limiter.Take(ctx, "", config.OperationRead); err != nil {
+					return statusError(err)
+				}
+			}
+		}
+
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// httpRequest builds the request seen by authenticators and tenant resolvers
+// from the metadata of a call.
+func httpRequest(ctx context.Context, fullMethod string) *http.Request {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := http.Header{}
+	for key, values := range md {
+		if strings.HasPrefix(key, ":") {
+			continue
+		}
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	r := &http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: fullMethod},
+		Header: header,
+	}
+	if authority := md.Get(":authority"); len(authority) > 0 {
+		r.Host = authority[0]
+	}
+	return r.WithContext(ctx)
+}
+
+// peerIP returns the IP address of the client of a call.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// reflectionServer serves the reflection service with the context of each
+// stream, so that it describes the tables the caller can reach in the schema
+// of its tenant.
+type reflectionServer struct {
+	reflectionv1.UnimplementedServerReflectionServer
+	server *Server
+}
+
+func (r reflectionServer) ServerReflectionInfo(stream reflectionv1.ServerReflection_ServerReflectionInfoServer) error {
+	return reflection.NewServerV1(r.server.reflectionOptions(stream.Context())).ServerReflectionInfo(stream)
+}
+
+type reflectionServerV1Alpha struct {
+	reflectionv1alpha.UnimplementedServerReflectionServer
+	server *Server
+}
+
+func (r reflectionServerV1Alpha) ServerReflectionInfo(stream reflectionv1alpha.ServerReflection_ServerReflectionInfoServer) error {
+	return reflection.NewServer(r.server.reflectionOptions(stream.Context())).ServerReflectionInfo(stream)
+}
+
+func (s *Server) reflectionOptions(ctx context.Context) reflection.ServerOptions {
+	return reflection.ServerOptions{
+		Services:           serviceInfoProvider{server: s, ctx: ctx},
+		DescriptorResolver: descriptorResolver{server: s, ctx: ctx},
+	}
+}
+
+type serviceInfoProvider struct {
+	server *Server
+	ctx    context.Context
+}
+
+func (p serviceInfoProvider) GetServiceInfo() map[string]grpc.ServiceInfo {
+	return p.server.serviceInfo(p.ctx)
+}
+
+// descriptorResolver serves the descriptors of the table files to the
+// reflection service. Other files come from the global registry.
+type descriptorResolver struct {
+	server *Server
+	ctx    context.Context
+}
+
+func (r descriptorResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	tableName, found := strings.CutPrefix(strings.TrimSuffix(path, ".proto"), packagePrefix+"/")
+	if !found {
+		return protoregistry.GlobalFiles.FindFileByPath(path)
+	}
+
+	_, file, err := r.server.tableFile(r.ctx, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", protoregistry.NotFound, err)
+	}
+	return file, nil
+}
+
+func (r descriptorResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	tableName, ok := tableFromFullName(string(name))
+	if !ok {
+		return protoregistry.GlobalFiles.FindDescriptorByName(name)
+	}
+
+	_, file, err := r.server.tableFile(r.ctx, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", protoregistry.NotFound, err)
+	}
+	files := &protoregistry.Files{}
+	if err := files.RegisterFile(file); err != nil {
+		return nil, err
+	}
+	return files.FindDescriptorByName(name)
+}
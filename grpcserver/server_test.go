@@ -0,0 +1,91 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"net"
+	"testing"
+	"time"
+)
+
+// startServer serves s on an in-memory listener and returns a connection to it.
+func startServer(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestCallsAreRateLimited(t *testing.T) {
+	cfg := &config.GenApiConfig{
+		GRPC: &config.GRPCConfig{},
+		RateLimit: &config.RateLimitConfig{
+			Default: config.RateLimit{Requests: 1, Per: time.Hour},
+		},
+	}
+	rateLimiter := middleware.NewRateLimiter(cfg, nil)
+	// Calls over the in-memory listener come from the address "bufconn".
+	rateLimiter.ForClient("ip:bufconn").Charge(context.Background(), "users", config.OperationCreate, 1)
+
+	conn := startServer(t, NewServer(cfg, nil, nil, nil, nil, rateLimiter))
+
+	tests := []struct {
+		method string
+		code   codes.Code
+	}{
+		{method: "/genapi.users.ItemService/Create", code: codes.ResourceExhausted},
+		{method: "/genapi.users.ItemService/Truncate", code: codes.Unimplemented},
+		{method: "/genapi.users.OtherService/Create", code: codes.Unimplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			err := conn.Invoke(context.Background(), tt.method, &emptypb.Empty{}, &emptypb.Empty{})
+			if status.Code(err) != tt.code {
+				t.Fatalf("Invoke() = %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s := NewServer(&config.GenApiConfig{GRPC: &config.GRPCConfig{}}, nil, nil, nil, nil, nil)
+	listener := bufconn.Listen(1 << 20)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(listener) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+
+	select {
+	case <-served:
+	case <-time.After(time.Second):
+		t.Fatal("Serve() did not return after Shutdown()")
+	}
+}
+
+func TestPeerIP(t *testing.T) {
+	if ip := peerIP(context.Background()); ip != "" {
+		t.Errorf("peerIP() without a peer = %q, want empty", ip)
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/service"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type APIKeyHandler struct {
+	service *service.APIKeyService
+}
+
+func NewAPIKeyHandler(service *service.APIKeyService) APIKeyHandler {
+	return APIKeyHandler{service: service}
+}
+
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req domains.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Errorf("handler: failed to bind JSON for api key request: %v", err)
+		utils.BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	key, err := h.service.CreateKey(c.Request.Context(), &req)
+	if err != nil {
+		logrus.Errorf("handler: failed to create api key: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to create api key")
+		return
+	}
+
+	utils.DataResponse(c, http.StatusCreated, key, "API key created successfully, store the key now as it cannot be shown again")
+}
+
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.service.ListKeys(c.Request.Context())
+	if err != nil {
+		logrus.Errorf("handler: failed to list api keys: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to list api keys")
+		return
+	}
+
+	utils.DataResponse(c, http.StatusOK, keys, "API keys retrieved successfully")
+}
+
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	if err := h.service.RevokeKey(c.Request.Context(), c.Param("id")); err != nil {
+		logrus.Errorf("handler: failed to revoke api key: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to revoke api key")
+		return
+	}
+
+	utils.DeletedResponse(c, "API key revoked successfully")
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/service"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditHandler struct {
+	service *service.AuditService
+}
+
+func NewAuditHandler(service *service.AuditService) AuditHandler {
+	return AuditHandler{service: service}
+}
+
+func (h *AuditHandler) GetHistory(c *gin.Context) {
+	tableName := c.Param("table_name")
+
+	entries, err := h.service.GetHistory(c.Request.Context(), tableName, c.Param("id"))
+	if err != nil {
+		logrus.Errorf("handler: failed to get audit history for table %s: %v", tableName, err)
+		utils.ServiceErrorResponse(c, err, "Failed to get audit history")
+		return
+	}
+
+	utils.DataResponse(c, http.StatusOK, entries, "Audit history retrieved successfully")
+}
@@ -0,0 +1,409 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	mimeCSV    = "text/csv"
+	mimeNDJSON = "application/x-ndjson"
+	mimeXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+	formatXLSX   = "xlsx"
+
+	// xlsxSheet names the sheet of an XLSX export.
+	xlsxSheet = "Sheet1"
+
+	// headerExportTruncated is set on exports cut at ExportMaxRows, as a
+	// trailer of streamed CSV exports.
+	headerExportTruncated = "X-Export-Truncated"
+
+	// A streamed response is flushed every exportFlushRows rows, or sooner
+	// when rows arrive slowly.
+	exportFlushRows     = 1000
+	exportFlushInterval = time.Second
+)
+
+// listFormat picks the format of a list response from the format query
+// parameter, falling back to the Accept header.
+func listFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	switch c.NegotiateFormat(gin.MIMEJSON, mimeCSV, mimeNDJSON, mimeXLSX) {
+	case mimeCSV:
+		return formatCSV
+	case mimeNDJSON:
+		return formatNDJSON
+	case mimeXLSX:
+		return formatXLSX
+	}
+	return formatJSON
+}
+
+// rowStreamer writes the rows of an export to the response. Nothing is
+// written until the columns are known, so errors raised before that still get
+// a JSON response.
+type rowStreamer interface {
+	domains.RowWriter
+	// started reports whether the response has been committed.
+	started() bool
+	// Flush sends the buffered rows to the client.
+	Flush() error
+}
+
+// streamFlusher flushes a streamed response periodically.
+type streamFlusher struct {
+	c         *gin.Context
+	rows      int
+	lastFlush time.Time
+}
+
+func (f *streamFlusher) begin(contentType string) {
+	f.c.Header("Content-Type", contentType)
+	f.c.Status(http.StatusOK)
+	f.lastFlush = time.Now()
+}
+
+// rowWritten counts a row and tells whether it is time to flush.
+func (f *streamFlusher) rowWritten() bool {
+	f.rows++
+	return f.rows%exportFlushRows == 0 || time.Since(f.lastFlush) >= exportFlushInterval
+}
+
+func (f *streamFlusher) flush() {
+	f.c.Writer.Flush()
+	f.lastFlush = time.Now()
+}
+
+// csvRowWriter streams rows as CSV with a header line. With escapeFormulas,
+// cells that spreadsheet applications would evaluate are escaped.
+type csvRowWriter struct {
+	streamFlusher
+	tableName      string
+	escapeFormulas bool
+	w              *csv.Writer
+	columns        []string
+	record         []string
+}
+
+func newCSVRowWriter(c *gin.Context, tableName string, escapeFormulas bool) *csvRowWriter {
+	return &csvRowWriter{streamFlusher: streamFlusher{c: c}, tableName: tableName, escapeFormulas: escapeFormulas}
+}
+
+func (w *csvRowWriter) started() bool {
+	return w.w != nil
+}
+
+func (w *csvRowWriter) WriteColumns(columns []domains.DatabaseColumn) error {
+	w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.tableName+".csv"))
+	w.begin(mimeCSV + "; charset=utf-8")
+
+	w.w = csv.NewWriter(w.c.Writer)
+	w.columns = columnNames(columns)
+	w.record = make([]string, len(columns))
+	return w.w.Write(w.columns)
+}
+
+func (w *csvRowWriter) WriteRow(row map[string]any) error {
+	for i, column := range w.columns {
+		value, err := csvValue(row[column])
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+		if w.escapeFormulas {
+			value = escapeFormula(value)
+		}
+		w.record[i] = value
+	}
+	if err := w.w.Write(w.record); err != nil {
+		return err
+	}
+
+	if w.rowWritten() {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Truncated marks the export as cut short. The header line has been sent
+// already, so the mark is a trailer.
+func (w *csvRowWriter) Truncated() {
+	w.c.Writer.Header().Set(http.TrailerPrefix+headerExportTruncated, "true")
+}
+
+func (w *csvRowWriter) Flush() error {
+	if !w.started() {
+		return nil
+	}
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		return err
+	}
+	w.flush()
+	return nil
+}
+
+// csvValue formats a cell. NULL is an empty cell, JSON values and arrays are
+// written as JSON.
+func csvValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// escapeFormula prefixes a cell that a spreadsheet application would evaluate
+// as a formula with ', which makes it text. Numbers such as -1 are kept.
+func escapeFormula(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+	return "'" + cell
+}
+
+// ndjsonRowWriter streams rows as newline delimited JSON, one object per row.
+type ndjsonRowWriter struct {
+	streamFlusher
+	encoder *json.Encoder
+}
+
+func newNDJSONRowWriter(c *gin.Context) *ndjsonRowWriter {
+	return &ndjsonRowWriter{streamFlusher: streamFlusher{c: c}}
+}
+
+func (w *ndjsonRowWriter) started() bool {
+	return w.encoder != nil
+}
+
+func (w *ndjsonRowWriter) WriteColumns([]domains.DatabaseColumn) error {
+	w.begin(mimeNDJSON)
+	w.encoder = json.NewEncoder(w.c.Writer)
+	return nil
+}
+
+func (w *ndjsonRowWriter) WriteRow(row map[string]any) error {
+	if err := w.encoder.Encode(row); err != nil {
+		return err
+	}
+	if w.rowWritten() {
+		return w.Flush()
+	}
+	return nil
+}
+
+func (w *ndjsonRowWriter) Flush() error {
+	if w.started() {
+		w.flush()
+	}
+	return nil
+}
+
+// xlsxRowWriter writes rows to a sheet of an XLSX workbook with a header row.
+// Numbers, booleans, dates and timestamps get typed cells, other values are
+// written like CSV cells. A workbook cannot be streamed, the rows are buffered
+// and sent on Flush.
+type xlsxRowWriter struct {
+	c         *gin.Context
+	tableName string
+	file      *excelize.File
+	w         *excelize.StreamWriter
+	columns   []domains.DatabaseColumn
+	styles    map[string]int
+	row       int
+	cells     []any
+	truncated bool
+	sent      bool
+}
+
+func newXLSXRowWriter(c *gin.Context, tableName string) *xlsxRowWriter {
+	return &xlsxRowWriter{c: c, tableName: tableName}
+}
+
+func (w *xlsxRowWriter) started() bool {
+	return w.sent
+}
+
+func (w *xlsxRowWriter) WriteColumns(columns []domains.DatabaseColumn) error {
+	w.file = excelize.NewFile()
+	stream, err := w.file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return err
+	}
+	w.w = stream
+
+	w.styles = make(map[string]int, 2)
+	for name, format := range map[string]string{"date": "yyyy-mm-dd", "timestamp": "yyyy-mm-dd hh:mm:ss"} {
+		style, err := w.file.NewStyle(&excelize.Style{CustomNumFmt: &format})
+		if err != nil {
+			return err
+		}
+		w.styles[name] = style
+	}
+
+	w.columns = columns
+	w.cells = make([]any, len(columns))
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	w.row = 1
+	return w.w.SetRow("A1", header)
+}
+
+func (w *xlsxRowWriter) WriteRow(row map[string]any) error {
+	for i, column := range w.columns {
+		cell, err := w.cell(column.DataType, row[column.Name])
+		if err != nil {
+			return fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		w.cells[i] = cell
+	}
+
+	w.row++
+	axis, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.w.SetRow(axis, w.cells)
+}
+
+// Truncated marks the workbook as holding only part of the rows.
+func (w *xlsxRowWriter) Truncated() {
+	w.truncated = true
+}
+
+// cell converts a value to a cell typed after the column.
+func (w *xlsxRowWriter) cell(dataType string, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch dataType {
+	case "smallint", "integer", "bigint", "real", "double precision", "numeric":
+		switch v := value.(type) {
+		case string:
+			if number, err := strconv.ParseFloat(v, 64); err == nil {
+				return number, nil
+			}
+		case json.Number:
+			if number, err := v.Float64(); err == nil {
+				return number, nil
+			}
+		case int, int32, int64, float32, float64:
+			return v, nil
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case string:
+			if boolean, err := strconv.ParseBool(v); err == nil {
+				return boolean, nil
+			}
+		case bool:
+			return v, nil
+		}
+	case "date":
+		if parsed, ok := cellTime(value, time.DateOnly); ok {
+			return excelize.Cell{StyleID: w.styles["date"], Value: parsed}, nil
+		}
+	case "timestamp without time zone", "timestamp with time zone":
+		if parsed, ok := cellTime(value, xlsxTimestampLayouts...); ok {
+			return excelize.Cell{StyleID: w.styles["timestamp"], Value: parsed}, nil
+		}
+	}
+
+	return csvValue(value)
+}
+
+func (w *xlsxRowWriter) Flush() error {
+	if w.file == nil || w.sent {
+		return nil
+	}
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+
+	w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.tableName+".xlsx"))
+	w.c.Header("Content-Type", mimeXLSX)
+	if w.truncated {
+		w.c.Header(headerExportTruncated, "true")
+	}
+	w.c.Status(http.StatusOK)
+	w.sent = true
+	return w.file.Write(w.c.Writer)
+}
+
+// Close removes the temporary files of the workbook.
+func (w *xlsxRowWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// xlsxTimestampLayouts are the formats of timestamps in rows. Cells have no
+// time zone, timestamps with one are written in UTC.
+var xlsxTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+}
+
+// cellTime reads a date or timestamp value in one of the layouts.
+func cellTime(value any, layouts ...string) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC(), true
+	case string:
+		for _, layout := range layouts {
+			if parsed, err := time.Parse(layout, v); err == nil {
+				return parsed.UTC(), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func columnNames(columns []domains.DatabaseColumn) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCSVValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "null", value: nil, want: ""},
+		{name: "string", value: "a,b", want: "a,b"},
+		{name: "bool", value: true, want: "true"},
+		{name: "int64", value: int64(-42), want: "-42"},
+		{name: "float", value: 1.5, want: "1.5"},
+		{name: "large float", value: 1e21, want: "1000000000000000000000"},
+		{name: "time", value: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), want: "2026-01-02T03:04:05Z"},
+		{name: "json object", value: map[string]any{"a": 1}, want: `{"a":1}`},
+		{name: "array", value: []any{"a", nil}, want: `["a",null]`},
+		{name: "json number", value: json.Number("12.50"), want: "12.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := csvValue(tt.value)
+			if err != nil {
+				t.Fatalf("csvValue() = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("csvValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{cell: "", want: ""},
+		{cell: "plain", want: "plain"},
+		{cell: "=1+2", want: "'=1+2"},
+		{cell: "+1-2", want: "'+1-2"},
+		{cell: "-2+3+cmd|' /C calc'!A0", want: "'-2+3+cmd|' /C calc'!A0"},
+		{cell: "@SUM(A1)", want: "'@SUM(A1)"},
+		{cell: "\t=1", want: "'\t=1"},
+		{cell: "\r=1", want: "'\r=1"},
+		{cell: "-42", want: "-42"},
+		{cell: "+1.5e3", want: "+1.5e3"},
+		{cell: "a=b", want: "a=b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cell, func(t *testing.T) {
+			if got := escapeFormula(tt.cell); got != tt.want {
+				t.Errorf("escapeFormula(%q) = %q, want %q", tt.cell, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVRowWriter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	columns := []domains.DatabaseColumn{{Name: "id"}, {Name: "name"}}
+	rows := []map[string]any{{"id": int64(1), "name": "=HYPERLINK(\"x\")"}, {"id": int64(-2), "name": nil}}
+
+	tests := []struct {
+		name           string
+		escapeFormulas bool
+		want           string
+	}{
+		{name: "escaped", escapeFormulas: true, want: "id,name\n1,\"'=HYPERLINK(\"\"x\"\")\"\n-2,\n"},
+		{name: "raw", want: "id,name\n1,\"=HYPERLINK(\"\"x\"\")\"\n-2,\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+
+			w := newCSVRowWriter(c, "users", tt.escapeFormulas)
+			if err := w.WriteColumns(columns); err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := w.WriteRow(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := recorder.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+				t.Errorf("Content-Type = %q", got)
+			}
+		})
+	}
+}
+
+func TestXLSXRowWriterTruncated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		truncated bool
+		want      string
+	}{
+		{name: "complete"},
+		{name: "truncated", truncated: true, want: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+
+			w := newXLSXRowWriter(c, "users")
+			defer w.Close()
+			if err := w.WriteColumns([]domains.DatabaseColumn{{Name: "id", DataType: "integer"}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteRow(map[string]any{"id": int64(1)}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.truncated {
+				w.Truncated()
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := recorder.Header().Get(headerExportTruncated); got != tt.want {
+				t.Errorf("%s = %q, want %q", headerExportTruncated, got, tt.want)
+			}
+			if recorder.Body.Len() == 0 {
+				t.Error("workbook was not sent")
+			}
+		})
+	}
+}
+
+func TestCSVRowWriterTruncated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		truncated bool
+		want      string
+	}{
+		{name: "complete"},
+		{name: "truncated", truncated: true, want: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+
+			var w domains.CappedRowWriter = newCSVRowWriter(c, "users", true)
+			if err := w.WriteColumns([]domains.DatabaseColumn{{Name: "id"}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteRow(map[string]any{"id": int64(1)}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.truncated {
+				w.Truncated()
+			}
+
+			response := recorder.Result()
+			if got := response.Trailer.Get(headerExportTruncated); got != tt.want {
+				t.Errorf("%s trailer = %q, want %q", headerExportTruncated, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNDJSONRowWriterUncapped(t *testing.T) {
+	if _, capped := any(newNDJSONRowWriter(nil)).(domains.CappedRowWriter); capped {
+		t.Error("NDJSON exports are capped, want every row streamed")
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/service"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/sirupsen/logrus"
+)
+
+type GraphQLHandler struct {
+	service *service.GraphQLService
+}
+
+func NewGraphQLHandler(service *service.GraphQLService) GraphQLHandler {
+	return GraphQLHandler{service: service}
+}
+
+func (h *GraphQLHandler) Execute(c *gin.Context) {
+	var req domains.GraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.Errorf("handler: failed to bind JSON for graphql request: %v", err)
+		utils.BadRequestResponse(c, "Invalid request body", err)
+		return
+	}
+
+	result, err := h.service.Execute(c.Request.Context(), &req)
+	if err != nil {
+		logrus.Errorf("handler: failed to execute graphql request: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to execute GraphQL request")
+		return
+	}
+
+	for i, formatted := range result.Errors {
+		result.Errors[i].Extensions = graphQLErrorExtensions(formatted)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// graphQLErrorExtensions reports the code, and the fields or constraint, of
+// errors returned by the service the same way the REST routes do.
+func graphQLErrorExtensions(formatted gqlerrors.FormattedError) map[string]any {
+	located, ok := formatted.OriginalError().(*gqlerrors.Error)
+	if !ok || located.OriginalError == nil {
+		return formatted.Extensions
+	}
+
+	response := utils.ClassifyError(located.OriginalError, "")
+	extensions := map[string]any{"code": response.Code}
+	if len(response.Fields) > 0 {
+		extensions["fields"] = response.Fields
+	}
+	if response.Constraint != "" {
+		extensions["constraint"] = response.Constraint
+	}
+	if response.Column != "" {
+		extensions["column"] = response.Column
+	}
+	return extensions
+}
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// maxNDJSONLine bounds the length of a single NDJSON record.
+const maxNDJSONLine = 16 << 20
+
+// xlsxUnzipRatio bounds the unpacked size of a workbook relative to its size.
+const xlsxUnzipRatio = 16
+
+// csvImportSource reads an import from CSV with a header line. Empty cells
+// are NULL.
+type csvImportSource struct {
+	r *csv.Reader
+}
+
+func newCSVImportSource(r io.Reader) *csvImportSource {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	return &csvImportSource{r: reader}
+}
+
+func (s *csvImportSource) Columns() ([]string, error) {
+	header, err := s.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := slices.Clone(header)
+	if len(columns) > 0 {
+		// Spreadsheet applications start UTF-8 files with a byte order mark.
+		columns[0] = strings.TrimPrefix(columns[0], "\ufeff")
+	}
+	return columns, nil
+}
+
+func (s *csvImportSource) Next() ([]any, error) {
+	record, err := s.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &domains.InvalidRowError{Message: parseErr.Err.Error()}
+		}
+		return nil, err
+	}
+
+	values := make([]any, len(record))
+	for i, cell := range record {
+		if cell != "" {
+			values[i] = cell
+		}
+	}
+	return values, nil
+}
+
+func (s *csvImportSource) Format() string {
+	return domains.ImportFormatCSV
+}
+
+// ndjsonImportSource reads an import from newline delimited JSON objects.
+// The keys of the first object are the columns, every other object must have
+// the same keys.
+type ndjsonImportSource struct {
+	scanner *bufio.Scanner
+	columns []string
+	first   map[string]any
+}
+
+func newNDJSONImportSource(r io.Reader) *ndjsonImportSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxNDJSONLine)
+	return &ndjsonImportSource{scanner: scanner}
+}
+
+func (s *ndjsonImportSource) Columns() ([]string, error) {
+	line, err := s.nextLine()
+	if err != nil {
+		return nil, err
+	}
+	first, err := decodeNDJSONObject(line)
+	if err != nil {
+		return nil, err
+	}
+
+	s.first = first
+	for key := range first {
+		s.columns = append(s.columns, key)
+	}
+	slices.Sort(s.columns)
+	return s.columns, nil
+}
+
+func (s *ndjsonImportSource) Next() ([]any, error) {
+	object := s.first
+	s.first = nil
+	if object == nil {
+		line, err := s.nextLine()
+		if err != nil {
+			return nil, err
+		}
+		if object, err = decodeNDJSONObject(line); err != nil {
+			return nil, &domains.InvalidRowError{Message: err.Error()}
+		}
+	}
+
+	values := make([]any, len(s.columns))
+	for i, column := range s.columns {
+		value, ok := object[column]
+		if !ok {
+			return nil, &domains.InvalidRowError{Message: fmt.Sprintf("missing key %s, every object needs the keys of the first one", column)}
+		}
+		values[i] = value
+	}
+	if len(object) > len(s.columns) {
+		for key := range object {
+			if !slices.Contains(s.columns, key) {
+				return nil, &domains.InvalidRowError{Message: fmt.Sprintf("unexpected key %s, every object needs the keys of the first one", key)}
+			}
+		}
+	}
+	return values, nil
+}
+
+func (s *ndjsonImportSource) Format() string {
+	return domains.ImportFormatNDJSON
+}
+
+// nextLine returns the next non-blank line, or io.EOF.
+func (s *ndjsonImportSource) nextLine() ([]byte, error) {
+	for s.scanner.Scan() {
+		if line := bytes.TrimSpace(s.scanner.Bytes()); len(line) > 0 {
+			return line, nil
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func decodeNDJSONObject(line []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var object map[string]any
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("invalid JSON object: %w", err)
+	}
+	if object == nil {
+		return nil, fmt.Errorf("invalid JSON object: null")
+	}
+	return object, nil
+}
+
+// xlsxImportSource reads an import from a sheet of an XLSX workbook. The
+// header is read from headerRow, counted from 1, and the rows above it are
+// skipped. Empty rows are skipped too.
+type xlsxImportSource struct {
+	file      *excelize.File
+	rows      *excelize.Rows
+	headerRow int
+}
+
+// newXLSXImportSource opens the workbook, which is read into memory, and
+// selects sheet or the active sheet when it is empty. Workbooks unpacking to
+// more than xlsxUnzipRatio times maxBytes are rejected, and worksheets larger
+// than maxBytes are unpacked to temporary files instead of memory.
+func newXLSXImportSource(r io.Reader, sheet string, headerRow int, maxBytes int64) (*xlsxImportSource, error) {
+	file, err := excelize.OpenReader(r, excelize.Options{
+		UnzipSizeLimit:    maxBytes * xlsxUnzipRatio,
+		UnzipXMLSizeLimit: maxBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %w", err)
+	}
+	if sheet == "" {
+		sheet = file.GetSheetName(file.GetActiveSheetIndex())
+	}
+
+	rows, err := file.Rows(sheet)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("sheet %q: %w", sheet, err)
+	}
+	return &xlsxImportSource{file: file, rows: rows, headerRow: headerRow}, nil
+}
+
+func (s *xlsxImportSource) Columns() ([]string, error) {
+	for row := 1; row < s.headerRow; row++ {
+		if !s.rows.Next() {
+			return nil, io.EOF
+		}
+	}
+	if !s.rows.Next() {
+		if err := s.rows.Error(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return s.rows.Columns()
+}
+
+func (s *xlsxImportSource) Next() ([]any, error) {
+	for s.rows.Next() {
+		cells, err := s.rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, &domains.InvalidRowError{Message: err.Error()}
+		}
+
+		values := make([]any, len(cells))
+		empty := true
+		for i, cell := range cells {
+			if cell != "" {
+				values[i] = cell
+				empty = false
+			}
+		}
+		if !empty {
+			return values, nil
+		}
+	}
+	if err := s.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (s *xlsxImportSource) Format() string {
+	return domains.ImportFormatXLSX
+}
+
+// Close releases the workbook.
+func (s *xlsxImportSource) Close() error {
+	s.rows.Close()
+	return s.file.Close()
+}
+
+// importSource reads the body of an import request by its content type. A
+// multipart form is read from its "file" field, whose format is taken from
+// the file extension. Workbooks are limited to xlsxMaxBytes.
+func importSource(c *gin.Context, xlsxMaxBytes int64) (domains.ImportSource, error) {
+	body, contentType := io.Reader(c.Request.Body), c.ContentType()
+	if contentType == gin.MIMEMultipartPOSTForm {
+		part, err := formFile(c, "file")
+		if err != nil {
+			return nil, err
+		}
+		body, contentType = part, importFileType(part)
+	}
+
+	switch contentType {
+	case mimeCSV:
+		return newCSVImportSource(body), nil
+	case mimeNDJSON:
+		return newNDJSONImportSource(body), nil
+	case mimeXLSX:
+		headerRow := 1
+		if headerRowStr := c.Query("header_row"); headerRowStr != "" {
+			parsed, err := strconv.Atoi(headerRowStr)
+			if err != nil || parsed < 1 {
+				return nil, fmt.Errorf("header_row must be a positive integer")
+			}
+			headerRow = parsed
+		}
+		body = http.MaxBytesReader(c.Writer, io.NopCloser(body), xlsxMaxBytes)
+		return newXLSXImportSource(body, c.Query("sheet"), headerRow, xlsxMaxBytes)
+	}
+	return nil, fmt.Errorf("unsupported content type %q, expected %s, %s or %s", contentType, mimeCSV, mimeNDJSON, mimeXLSX)
+}
+
+// formFile returns the part of a multipart form holding the named file
+// without buffering the form.
+func formFile(c *gin.Context, name string) (*multipart.Part, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("missing %s field", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == name {
+			return part, nil
+		}
+	}
+}
+
+// importFileType picks the format of an uploaded file from its extension,
+// falling back to the content type of the part.
+func importFileType(part *multipart.Part) string {
+	switch strings.ToLower(path.Ext(part.FileName())) {
+	case ".csv":
+		return mimeCSV
+	case ".ndjson", ".jsonl":
+		return mimeNDJSON
+	case ".xlsx":
+		return mimeXLSX
+	}
+	contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	return contentType
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// readImport reads every row of source, stopping at the first error.
+func readImport(source domains.ImportSource) ([]string, [][]any, error) {
+	columns, err := source.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows [][]any
+	for {
+		row, err := source.Next()
+		if err == io.EOF {
+			return columns, rows, nil
+		}
+		if err != nil {
+			return columns, rows, err
+		}
+		rows = append(rows, slices.Clone(row))
+	}
+}
+
+func TestCSVImportSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		columns    []string
+		rows       [][]any
+		invalidRow bool
+	}{
+		{name: "rows", body: "id,name\n1,a\n2,b\n", columns: []string{"id", "name"}, rows: [][]any{{"1", "a"}, {"2", "b"}}},
+		{name: "empty cells are null", body: "id,name\n1,\n", columns: []string{"id", "name"}, rows: [][]any{{"1", nil}}},
+		{name: "byte order mark", body: "\ufeffid,name\n1,a\n", columns: []string{"id", "name"}, rows: [][]any{{"1", "a"}}},
+		{name: "quoted cells", body: "id,name\n1,\"a, \"\"b\"\"\"\n", columns: []string{"id", "name"}, rows: [][]any{{"1", `a, "b"`}}},
+		{name: "wrong number of cells", body: "id,name\n1\n", columns: []string{"id", "name"}, invalidRow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, rows, err := readImport(newCSVImportSource(strings.NewReader(tt.body)))
+			var rowErr *domains.InvalidRowError
+			if tt.invalidRow != errors.As(err, &rowErr) {
+				t.Fatalf("read error = %v, want invalid row %v", err, tt.invalidRow)
+			}
+			if !tt.invalidRow && err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(columns, tt.columns) || !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("read = %v %v, want %v %v", columns, rows, tt.columns, tt.rows)
+			}
+		})
+	}
+}
+
+func TestNDJSONImportSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		columns    []string
+		rows       [][]any
+		invalidRow bool
+	}{
+		{name: "columns are sorted keys", body: `{"name":"a","id":1}` + "\n" + `{"id":2,"name":null}`, columns: []string{"id", "name"}, rows: [][]any{{"1", "a"}, {"2", nil}}},
+		{name: "blank lines", body: "\n" + `{"id":1}` + "\n\n", columns: []string{"id"}, rows: [][]any{{"1"}}},
+		{name: "nested values", body: `{"tags":["a"],"meta":{"b":true}}`, columns: []string{"meta", "tags"}, rows: [][]any{{map[string]any{"b": true}, []any{"a"}}}},
+		{name: "missing key", body: `{"id":1,"name":"a"}` + "\n" + `{"id":2}`, columns: []string{"id", "name"}, rows: [][]any{{"1", "a"}}, invalidRow: true},
+		{name: "unexpected key", body: `{"id":1}` + "\n" + `{"id":2,"name":"b"}`, columns: []string{"id"}, rows: [][]any{{"1"}}, invalidRow: true},
+		{name: "invalid object", body: `{"id":1}` + "\n" + `[1]`, columns: []string{"id"}, rows: [][]any{{"1"}}, invalidRow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, rows, err := readImport(newNDJSONImportSource(strings.NewReader(tt.body)))
+			var rowErr *domains.InvalidRowError
+			if tt.invalidRow != errors.As(err, &rowErr) {
+				t.Fatalf("read error = %v, want invalid row %v", err, tt.invalidRow)
+			}
+			if !tt.invalidRow && err != nil {
+				t.Fatal(err)
+			}
+			// Numbers are decoded as json.Number, compare them as strings.
+			for _, row := range rows {
+				for i, value := range row {
+					if number, ok := value.(interface{ String() string }); ok {
+						row[i] = number.String()
+					}
+				}
+			}
+			if !reflect.DeepEqual(columns, tt.columns) || !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("read = %v %v, want %v %v", columns, rows, tt.columns, tt.rows)
+			}
+		})
+	}
+}
+
+func TestXLSXImportSource(t *testing.T) {
+	workbook := excelize.NewFile()
+	sheet := workbook.GetSheetName(0)
+	cells := [][]any{{"Users export"}, {"id", "name"}, {1, "a"}, {}, {2, nil}}
+	for i, row := range cells {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := workbook.SetSheetRow(sheet, cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := workbook.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		sheet     string
+		headerRow int
+		maxBytes  int64
+		columns   []string
+		rows      [][]any
+		wantErr   bool
+	}{
+		{name: "header row", headerRow: 2, maxBytes: 1 << 20, columns: []string{"id", "name"}, rows: [][]any{{"1", "a"}, {"2"}}},
+		{name: "named sheet", sheet: sheet, headerRow: 2, maxBytes: 1 << 20, columns: []string{"id", "name"}, rows: [][]any{{"1", "a"}, {"2"}}},
+		{name: "first row header", headerRow: 1, maxBytes: 1 << 20, columns: []string{"Users export"}, rows: [][]any{{"id", "name"}, {"1", "a"}, {"2"}}},
+		{name: "unknown sheet", sheet: "Missing", headerRow: 1, maxBytes: 1 << 20, wantErr: true},
+		{name: "unpacked workbook too large", headerRow: 1, maxBytes: 512, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := newXLSXImportSource(bytes.NewReader(buf.Bytes()), tt.sheet, tt.headerRow, tt.maxBytes)
+			if tt.wantErr {
+				if err == nil {
+					source.Close()
+					t.Fatal("newXLSXImportSource() = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newXLSXImportSource() = %v", err)
+			}
+			defer source.Close()
+
+			columns, rows, err := readImport(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(columns, tt.columns) || !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("read = %v %v, want %v %v", columns, rows, tt.columns, tt.rows)
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@ package handler
 
 import (
 	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
 	"github.com/abdulaziz-go/go-gen-apis/domains"
 	"github.com/abdulaziz-go/go-gen-apis/service"
 	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"io"
+	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -14,10 +17,11 @@ import (
 
 type ItemHandler struct {
 	service *service.ItemService
+	cfg     *config.GenApiConfig
 }
 
-func NewItemHandler(service *service.ItemService) ItemHandler {
-	return ItemHandler{service: service}
+func NewItemHandler(service *service.ItemService, cfg *config.GenApiConfig) ItemHandler {
+	return ItemHandler{service: service, cfg: cfg}
 }
 
 func (h *ItemHandler) CreateItem(c *gin.Context) {
@@ -28,8 +32,8 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 	}
 
 	var req domains.CreateItemRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.Errorf("handler: failed to bind JSON for create request: %v", err)
+	if err := utils.BindBody(c, &req); err != nil {
+		logrus.Errorf("handler: failed to bind body of create request: %v", err)
 		utils.BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
@@ -37,11 +41,7 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 	items, err := h.service.CreateItem(c.Request.Context(), tableName, &req)
 	if err != nil {
 		logrus.Errorf("handler: failed to create items: %v", err)
-		if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "cannot be") {
-			utils.ValidationErrorResponse(c, "Validation failed", err)
-			return
-		}
-		utils.InternalErrorResponse(c, "Failed to create items", err)
+		utils.ServiceErrorResponse(c, err, "Failed to create items")
 		return
 	}
 
@@ -52,6 +52,48 @@ func (h *ItemHandler) CreateItem(c *gin.Context) {
 	}
 }
 
+// ImportItems bulk loads rows from a CSV, NDJSON or XLSX body. With dry_run=true the
+// rows are checked, including database constraints, but not kept.
+func (h *ItemHandler) ImportItems(c *gin.Context) {
+	tableName := c.Param("table_name")
+	if tableName == "" {
+		utils.BadRequestResponse(c, "Table name is required", nil)
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := c.Query("dry_run"); dryRunStr != "" {
+		parsed, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid dry_run parameter", err)
+			return
+		}
+		dryRun = parsed
+	}
+
+	source, err := importSource(c, h.cfg.GetXLSXImportMaxBytes())
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid import file", err)
+		return
+	}
+	if closer, ok := source.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	result, err := h.service.ImportItems(c.Request.Context(), tableName, source, dryRun)
+	if err != nil {
+		logrus.Errorf("handler: failed to import items: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to import items")
+		return
+	}
+
+	if dryRun {
+		utils.DataResponse(c, http.StatusOK, result, fmt.Sprintf("%d rows validated", result.Rows))
+		return
+	}
+	utils.DataResponse(c, http.StatusCreated, result, fmt.Sprintf("%d rows imported", result.Rows))
+}
+
 func (h *ItemHandler) GetItemByID(c *gin.Context) {
 	tableName := c.Param("table_name")
 	if tableName == "" {
@@ -65,18 +107,16 @@ func (h *ItemHandler) GetItemByID(c *gin.Context) {
 		return
 	}
 
-	item, err := h.service.GetSingleItem(c.Request.Context(), tableName, id)
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid as_of parameter", err)
+		return
+	}
+
+	item, err := h.service.GetSingleItem(c.Request.Context(), tableName, id, asOf)
 	if err != nil {
 		logrus.Errorf("handler: failed to get item by ID: %v", err)
-		if strings.Contains(err.Error(), "not found") {
-			utils.NotFoundResponse(c, "Item not found", err)
-			return
-		}
-		if strings.Contains(err.Error(), "invalid") {
-			utils.BadRequestResponse(c, "Invalid ID", err)
-			return
-		}
-		utils.InternalErrorResponse(c, "Failed to get item", err)
+		utils.ServiceErrorResponse(c, err, "Failed to get item")
 		return
 	}
 
@@ -90,6 +130,62 @@ func (h *ItemHandler) GetItems(c *gin.Context) {
 		return
 	}
 
+	filter, err := itemFilterFromQuery(c)
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid as_of parameter", err)
+		return
+	}
+
+	switch format := listFormat(c); format {
+	case formatJSON:
+	case formatCSV:
+		h.exportItems(c, tableName, filter, newCSVRowWriter(c, tableName, !h.cfg.CSVRawCells))
+		return
+	case formatNDJSON:
+		h.exportItems(c, tableName, filter, newNDJSONRowWriter(c))
+		return
+	case formatXLSX:
+		h.exportItems(c, tableName, filter, newXLSXRowWriter(c, tableName))
+		return
+	default:
+		utils.BadRequestResponse(c, "Invalid format parameter", fmt.Errorf("unsupported format %q", format))
+		return
+	}
+
+	items, total, err := h.service.GetItems(c.Request.Context(), tableName, filter)
+	if err != nil {
+		logrus.Errorf("handler: failed to get items: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to get items")
+		return
+	}
+
+	utils.ListResponse(c, items, total, filter.Limit, filter.Offset, "Items retrieved successfully")
+}
+
+// exportItems streams the rows to w. Once rows have been sent, a failure can
+// only cut the response short.
+func (h *ItemHandler) exportItems(c *gin.Context, tableName string, filter *domains.ItemFilter, w rowStreamer) {
+	if closer, ok := w.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	err := h.service.ExportItems(c.Request.Context(), tableName, filter, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		logrus.Errorf("handler: failed to export items: %v", err)
+		if w.started() {
+			c.Abort()
+			return
+		}
+		utils.ServiceErrorResponse(c, err, "Failed to export items")
+	}
+}
+
+// itemFilterFromQuery reads the filter of a list request. Parameters that are
+// not reserved filter on the column of the same name.
+func itemFilterFromQuery(c *gin.Context) (*domains.ItemFilter, error) {
 	filter := &domains.ItemFilter{
 		Filters: make(map[string]interface{}),
 	}
@@ -109,8 +205,14 @@ func (h *ItemHandler) GetItems(c *gin.Context) {
 	filter.OrderBy = c.Query("order_by")
 	filter.Sort = c.Query("sort")
 
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.AsOf = asOf
+
 	for key, values := range c.Request.URL.Query() {
-		if key == "limit" || key == "offset" || key == "order_by" || key == "sort" || key == "search" {
+		if key == "limit" || key == "offset" || key == "order_by" || key == "sort" || key == "search" || key == "as_of" || key == "format" {
 			continue
 		}
 		if len(values) > 0 {
@@ -126,20 +228,25 @@ func (h *ItemHandler) GetItems(c *gin.Context) {
 		}
 	}
 
-	searchValue := c.Query("search")
-	filter.Search = searchValue
-	items, total, err := h.service.GetItems(c.Request.Context(), tableName, filter)
+	filter.Search = c.Query("search")
+	return filter, nil
+}
+
+func (h *ItemHandler) GetItemHistory(c *gin.Context) {
+	tableName := c.Param("table_name")
+	if tableName == "" {
+		utils.BadRequestResponse(c, "Table name is required", nil)
+		return
+	}
+
+	versions, err := h.service.GetItemHistory(c.Request.Context(), tableName, c.Param("id"))
 	if err != nil {
-		logrus.Errorf("handler: failed to get items: %v", err)
-		if strings.Contains(err.Error(), "invalid") {
-			utils.ValidationErrorResponse(c, "Validation failed", err)
-			return
-		}
-		utils.InternalErrorResponse(c, "Failed to get items", err)
+		logrus.Errorf("handler: failed to get item history: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to get item history")
 		return
 	}
 
-	utils.ListResponse(c, items, total, filter.Limit, filter.Offset, "Items retrieved successfully")
+	utils.DataResponse(c, http.StatusOK, versions, "Item history retrieved successfully")
 }
 
 func (h *ItemHandler) UpdateItem(c *gin.Context) {
@@ -156,8 +263,8 @@ func (h *ItemHandler) UpdateItem(c *gin.Context) {
 	}
 
 	var req domains.UpdateItemRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logrus.Errorf("handler: failed to bind JSON for update request: %v", err)
+	if err := utils.BindBody(c, &req); err != nil {
+		logrus.Errorf("handler: failed to bind body of update request: %v", err)
 		utils.BadRequestResponse(c, "Invalid request body", err)
 		return
 	}
@@ -165,15 +272,7 @@ func (h *ItemHandler) UpdateItem(c *gin.Context) {
 	item, err := h.service.UpdateItem(c.Request.Context(), tableName, id, &req)
 	if err != nil {
 		logrus.Errorf("handler: failed to update item: %v", err)
-		if strings.Contains(err.Error(), "not found") {
-			utils.NotFoundResponse(c, "Item not found", err)
-			return
-		}
-		if strings.Contains(err.Error(), "invalid") {
-			utils.ValidationErrorResponse(c, "Validation failed", err)
-			return
-		}
-		utils.InternalErrorResponse(c, "Failed to update item", err)
+		utils.ServiceErrorResponse(c, err, "Failed to update item")
 		return
 	}
 
@@ -196,17 +295,23 @@ func (h *ItemHandler) DeleteItem(c *gin.Context) {
 	err := h.service.DeleteItem(c.Request.Context(), tableName, id)
 	if err != nil {
 		logrus.Errorf("handler: failed to delete item: %v", err)
-		if strings.Contains(err.Error(), "not found") {
-			utils.NotFoundResponse(c, "Item not found", err)
-			return
-		}
-		if strings.Contains(err.Error(), "invalid") {
-			utils.BadRequestResponse(c, "Invalid ID", err)
-			return
-		}
-		utils.InternalErrorResponse(c, "Failed to delete item", err)
+		utils.ServiceErrorResponse(c, err, "Failed to delete item")
 		return
 	}
 
 	utils.DeletedResponse(c, "Item deleted successfully")
 }
+
+// parseAsOf reads the optional RFC 3339 as_of query parameter.
+func parseAsOf(c *gin.Context) (*time.Time, error) {
+	value := c.Query("as_of")
+	if value == "" {
+		return nil, nil
+	}
+
+	asOf, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("as_of must be an RFC 3339 timestamp: %w", err)
+	}
+	return &asOf, nil
+}
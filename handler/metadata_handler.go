@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/service"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type MetadataHandler struct {
+	service *service.MetadataService
+}
+
+func NewMetadataHandler(service *service.MetadataService) MetadataHandler {
+	return MetadataHandler{service: service}
+}
+
+func (h *MetadataHandler) ListTables(c *gin.Context) {
+	tables, err := h.service.ListTables(c.Request.Context())
+	if err != nil {
+		logrus.Errorf("handler: failed to list tables: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to list tables")
+		return
+	}
+
+	utils.DataResponse(c, http.StatusOK, tables, "Tables retrieved successfully")
+}
+
+func (h *MetadataHandler) GetTable(c *gin.Context) {
+	tableName := c.Param("table_name")
+
+	info, err := h.service.TableInfo(c.Request.Context(), tableName)
+	if err != nil {
+		logrus.Errorf("handler: failed to get metadata of table %s: %v", tableName, err)
+		utils.ServiceErrorResponse(c, err, "Failed to get table")
+		return
+	}
+
+	utils.DataResponse(c, http.StatusOK, info, "Table retrieved successfully")
+}
+
+func (h *MetadataHandler) GetJSONSchema(c *gin.Context) {
+	tableName := c.Param("table_name")
+
+	schema, err := h.service.TableJSONSchema(c.Request.Context(), tableName)
+	if err != nil {
+		logrus.Errorf("handler: failed to get json schema of table %s: %v", tableName, err)
+		utils.ServiceErrorResponse(c, err, "Failed to get table schema")
+		return
+	}
+
+	c.Header("Content-Type", "application/schema+json")
+	c.JSON(http.StatusOK, schema)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	_ "embed"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/service"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"html/template"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SwaggerUIAssetsPath is where OpenAPIConfig.SwaggerUIAssets are served,
+// relative to the route group.
+const SwaggerUIAssetsPath = "/docs/assets"
+
+//go:embed swagger_ui.html
+var swaggerUIPage string
+
+var swaggerUITemplate = template.Must(template.New("swagger_ui").Parse(swaggerUIPage))
+
+type OpenAPIHandler struct {
+	service  *service.OpenAPIService
+	cfg      *config.OpenAPIConfig
+	basePath string
+}
+
+func NewOpenAPIHandler(service *service.OpenAPIService, cfg *config.OpenAPIConfig, basePath string) OpenAPIHandler {
+	return OpenAPIHandler{service: service, cfg: cfg, basePath: basePath}
+}
+
+func (h *OpenAPIHandler) GetDocument(c *gin.Context) {
+	document, err := h.service.Document(c.Request.Context(), h.basePath)
+	if err != nil {
+		logrus.Errorf("handler: failed to build openapi document: %v", err)
+		utils.ServiceErrorResponse(c, err, "Failed to build OpenAPI document")
+		return
+	}
+
+	c.JSON(http.StatusOK, document)
+}
+
+func (h *OpenAPIHandler) SwaggerUI(c *gin.Context) {
+	assetsURL := h.cfg.GetSwaggerUIAssetsURL()
+	if h.cfg.SwaggerUIAssets != nil {
+		assetsURL = path.Join(h.basePath, SwaggerUIAssetsPath)
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err := swaggerUITemplate.Execute(c.Writer, map[string]string{
+		"Title":        h.cfg.GetTitle(),
+		"AssetsURL":    assetsURL,
+		"CSSIntegrity": h.cfg.SwaggerUIIntegrity.CSS,
+		"JSIntegrity":  h.cfg.SwaggerUIIntegrity.JS,
+		"SpecURL":      path.Join(h.basePath, "openapi.json"),
+	})
+	if err != nil {
+		logrus.Errorf("handler: failed to render swagger ui: %v", err)
+	}
+}
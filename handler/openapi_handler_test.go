@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSwaggerUI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		cfg     config.OpenAPIConfig
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "pinned cdn",
+			want: []string{
+				`href="` + config.DefaultSwaggerUIAssetsURL + `/swagger-ui.css">`,
+				`src="` + config.DefaultSwaggerUIAssetsURL + `/swagger-ui-bundle.js" crossorigin="anonymous">`,
+				`url: "\/api\/v1\/openapi.json"`,
+			},
+			notWant: []string{"integrity"},
+		},
+		{
+			name: "integrity",
+			cfg:  config.OpenAPIConfig{SwaggerUIIntegrity: config.SwaggerUIIntegrity{CSS: "sha384-css", JS: "sha384-js"}},
+			want: []string{
+				`swagger-ui.css" integrity="sha384-css" crossorigin="anonymous">`,
+				`swagger-ui-bundle.js" integrity="sha384-js" crossorigin="anonymous">`,
+			},
+		},
+		{
+			name: "served assets",
+			cfg:  config.OpenAPIConfig{SwaggerUIAssetsURL: "https://cdn.example.com/", SwaggerUIAssets: fstest.MapFS{}},
+			want: []string{
+				`href="/api/v1/docs/assets/swagger-ui.css">`,
+				`src="/api/v1/docs/assets/swagger-ui-bundle.js"`,
+			},
+			notWant: []string{"cdn.example.com"},
+		},
+		{
+			name:    "escaped title",
+			cfg:     config.OpenAPIConfig{Title: "<script>"},
+			want:    []string{"<title>&lt;script&gt;</title>"},
+			notWant: []string{"<title><script>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+
+			h := NewOpenAPIHandler(nil, &tt.cfg, "/api/v1")
+			h.SwaggerUI(c)
+
+			body := recorder.Body.String()
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("page does not contain %s:\n%s", want, body)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(body, notWant) {
+					t.Errorf("page contains %s:\n%s", notWant, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/service"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strings"
+)
+
+// PrincipalKey is the gin context key holding the authenticated principal.
+const PrincipalKey = "genapi.principal"
+
+// ErrAuthenticationRequired is returned by a RequestAuthenticator for
+// requests without credentials when anonymous access is disabled.
+var ErrAuthenticationRequired = errors.New("authentication required")
+
+// RequestAuthenticator identifies the principal of a request.
+type RequestAuthenticator func(r *http.Request) (*domains.Principal, error)
+
+// Authenticate builds the authentication middleware of an authenticator.
+func Authenticate(authenticate RequestAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticate(c.Request)
+		if errors.Is(err, ErrAuthenticationRequired) {
+			utils.UnauthorizedResponse(c, "Authentication required", nil)
+			c.Abort()
+			return
+		}
+		if err != nil {
+			logrus.Warnf("authentication failed: %v", err)
+			utils.UnauthorizedResponse(c, "Authentication failed", err)
+			c.Abort()
+			return
+		}
+
+		SetPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// NewRequestAuthenticator builds the authenticator described by cfg.Auth. It
+// returns nil when authentication is not configured. API keys, when enabled,
+// take precedence over the bearer token authenticator.
+func NewRequestAuthenticator(cfg *config.GenApiConfig, apiKeys *service.APIKeyService) (RequestAuthenticator, error) {
+	if cfg.Auth == nil {
+		return nil, nil
+	}
+
+	authenticate := cfg.Auth.Authenticator
+	if authenticate == nil && cfg.Auth.JWT != nil {
+		verifier, err := NewJWTVerifier(cfg.Auth.JWT)
+		if err != nil {
+			return nil, err
+		}
+		authenticate = verifier.AuthenticateRequest
+	}
+	if authenticate == nil && cfg.Auth.APIKeys == nil {
+		return nil, errors.New("auth config requires an authenticator, a jwt config or api keys")
+	}
+
+	var apiKeyHeader string
+	if cfg.Auth.APIKeys != nil {
+		if apiKeys == nil {
+			return nil, errors.New("api keys are enabled but no api key service was provided")
+		}
+		apiKeyHeader = cfg.Auth.APIKeys.GetHeader()
+	}
+
+	anonymousRole := cfg.Auth.AnonymousRole
+
+	return func(r *http.Request) (*domains.Principal, error) {
+		var principal *domains.Principal
+		var err error
+		if rawKey := r.Header.Get(apiKeyHeader); apiKeyHeader != "" && rawKey != "" {
+			principal, err = apiKeys.Authenticate(r.Context(), rawKey)
+		} else if authenticate != nil {
+			principal, err = authenticate(r)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if principal == nil {
+			if anonymousRole == "" {
+				return nil, ErrAuthenticationRequired
+			}
+			principal = &domains.Principal{Role: anonymousRole}
+		}
+		return principal, nil
+	}, nil
+}
+
+// SetPrincipal stores the principal on both the gin context and the request
+// context so that it reaches the service layer.
+func SetPrincipal(c *gin.Context, principal *domains.Principal) {
+	c.Set(PrincipalKey, principal)
+	c.Request = c.Request.WithContext(domains.ContextWithPrincipal(c.Request.Context(), principal))
+}
+
+// AuthenticateRequest verifies the bearer token of the request. Requests
+// without an Authorization header yield a nil principal.
+func (v *JWTVerifier) AuthenticateRequest(r *http.Request) (*domains.Principal, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, errors.New("authorization header must use the Bearer scheme")
+	}
+
+	return v.Verify(strings.TrimSpace(token))
+}
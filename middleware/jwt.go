@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+)
+
+// JWTVerifier validates HS256 and RS256 bearer tokens against static keys or
+// a local JWKS file.
+type JWTVerifier struct {
+	cfg        *config.JWTConfig
+	secret     []byte
+	publicKey  *rsa.PublicKey
+	hmacKeys   map[string][]byte
+	rsaKeys    map[string]*rsa.PublicKey
+	algorithms []string
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+func NewJWTVerifier(cfg *config.JWTConfig) (*JWTVerifier, error) {
+	if cfg == nil {
+		return nil, errors.New("jwt config is nil")
+	}
+
+	verifier := &JWTVerifier{
+		cfg:      cfg,
+		secret:   cfg.Secret,
+		hmacKeys: make(map[string][]byte),
+		rsaKeys:  make(map[string]*rsa.PublicKey),
+	}
+
+	if cfg.PublicKeyFile != "" {
+		pemBytes, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwt public key: %w", err)
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse jwt public key: %w", err)
+		}
+		verifier.publicKey = publicKey
+	}
+
+	if cfg.JWKSFile != "" {
+		if err := verifier.loadJWKS(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(verifier.secret) > 0 || len(verifier.hmacKeys) > 0 {
+		verifier.algorithms = append(verifier.algorithms, jwt.SigningMethodHS256.Alg())
+	}
+	if verifier.publicKey != nil || len(verifier.rsaKeys) > 0 {
+		verifier.algorithms = append(verifier.algorithms, jwt.SigningMethodRS256.Alg())
+	}
+	if len(verifier.algorithms) == 0 {
+		return nil, errors.New("jwt config requires a secret, a public key file or a jwks file")
+	}
+
+	return verifier, nil
+}
+
+func (v *JWTVerifier) loadJWKS(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read jwks file: %w", err)
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &keySet); err != nil {
+		return fmt.Errorf("failed to parse jwks file: %w", err)
+	}
+
+	for _, key := range keySet.Keys {
+		switch key.Kty {
+		case "RSA":
+			publicKey, err := key.rsaPublicKey()
+			if err != nil {
+				return fmt.Errorf("invalid rsa key %q in jwks: %w", key.Kid, err)
+			}
+			v.rsaKeys[key.Kid] = publicKey
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return fmt.Errorf("invalid symmetric key %q in jwks: %w", key.Kid, err)
+			}
+			v.hmacKeys[key.Kid] = secret
+		}
+	}
+
+	return nil
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+// Verify parses the token and turns its claims into a principal. Tokens must
+// expire.
+func (v *JWTVerifier) Verify(tokenString string) (*domains.Principal, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(v.algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(v.cfg.Leeway),
+	}
+	if v.cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(v.cfg.Issuer))
+	}
+	if v.cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(v.cfg.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, claims, v.keyFunc, options...); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	principal := &domains.Principal{Claims: claims}
+	if subject, err := claims.GetSubject(); err == nil {
+		principal.Subject = subject
+	}
+	if role, ok := claims[v.cfg.GetRoleClaim()].(string); ok {
+		principal.Role = role
+	}
+
+	return principal, nil
+}
+
+func (v *JWTVerifier) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if key, ok := v.hmacKeys[kid]; ok {
+			return key, nil
+		}
+		if len(v.secret) > 0 {
+			return v.secret, nil
+		}
+		if key, ok := singleKey(v.hmacKeys); ok && kid == "" {
+			return key, nil
+		}
+	case jwt.SigningMethodRS256.Alg():
+		if key, ok := v.rsaKeys[kid]; ok {
+			return key, nil
+		}
+		if v.publicKey != nil {
+			return v.publicKey, nil
+		}
+		if key, ok := singleKey(v.rsaKeys); ok && kid == "" {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no key found for algorithm %s and kid %q", token.Method.Alg(), kid)
+}
+
+func singleKey[T any](keys map[string]T) (T, bool) {
+	var zero T
+	if len(keys) != 1 {
+		return zero, false
+	}
+	for _, key := range keys {
+		return key, true
+	}
+	return zero, false
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/golang-jwt/jwt/v5"
+	"testing"
+	"time"
+)
+
+func TestJWTVerifierVerify(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Now()
+	sign := func(method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": "1", "role": "web_user", "iss": "issuer", "aud": "api", "exp": now.Add(time.Hour).Unix()}
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		leeway  time.Duration
+		wantErr bool
+	}{
+		{name: "valid", token: sign(jwt.SigningMethodHS256, secret, valid())},
+		{name: "missing exp", token: sign(jwt.SigningMethodHS256, secret, with("exp", nil)), wantErr: true},
+		{name: "expired", token: sign(jwt.SigningMethodHS256, secret, with("exp", now.Add(-time.Minute).Unix())), wantErr: true},
+		{name: "expired within leeway", token: sign(jwt.SigningMethodHS256, secret, with("exp", now.Add(-time.Minute).Unix())), leeway: 2 * time.Minute},
+		{name: "not yet valid", token: sign(jwt.SigningMethodHS256, secret, with("nbf", now.Add(time.Hour).Unix())), wantErr: true},
+		{name: "issued in the future", token: sign(jwt.SigningMethodHS256, secret, with("iat", now.Add(time.Hour).Unix())), wantErr: true},
+		{name: "wrong issuer", token: sign(jwt.SigningMethodHS256, secret, with("iss", "other")), wantErr: true},
+		{name: "wrong audience", token: sign(jwt.SigningMethodHS256, secret, with("aud", "other")), wantErr: true},
+		{name: "wrong secret", token: sign(jwt.SigningMethodHS256, []byte("other"), valid()), wantErr: true},
+		{name: "unsigned", token: sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid()), wantErr: true},
+		{name: "malformed", token: "not.a.token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewJWTVerifier(&config.JWTConfig{Secret: secret, Issuer: "issuer", Audience: "api", Leeway: tt.leeway})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			principal, err := verifier.Verify(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (principal.Subject != "1" || principal.Role != "web_user") {
+				t.Errorf("Verify() = %+v, want subject 1 with role web_user", principal)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/service"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+var methodOperations = map[string]string{
+	http.MethodPost:   config.OperationCreate,
+	http.MethodGet:    config.OperationRead,
+	http.MethodPut:    config.OperationUpdate,
+	http.MethodDelete: config.OperationDelete,
+}
+
+// RateLimiter keeps token buckets per client, table and operation.
+type RateLimiter struct {
+	cfg   *config.RateLimitConfig
+	store config.RateLimitStore
+	items *service.ItemService
+}
+
+// NewRateLimiter builds the rate limiter described by cfg.RateLimit. It
+// returns nil when rate limiting is not configured.
+func NewRateLimiter(cfg *config.GenApiConfig, items *service.ItemService) *RateLimiter {
+	if cfg.RateLimit == nil {
+		return nil
+	}
+
+	store := cfg.RateLimit.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	return &RateLimiter{cfg: cfg.RateLimit, store: store, items: items}
+}
+
+// Handler attaches the limiter of the client to the request and takes a token
+// for the table of the route and the operation of the method.
+func (l *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := l.attach(c)
+
+		status, err := limiter.take(c.Request.Context(), c.Param("table_name"), methodOperations[c.Request.Method])
+		if status != nil {
+			resetSeconds := int(math.Ceil(status.result.ResetAfter.Seconds()))
+			c.Header("RateLimit-Limit", strconv.Itoa(status.limit.Requests))
+			c.Header("RateLimit-Remaining", strconv.Itoa(status.result.Remaining))
+			c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+			if !status.result.Allowed {
+				c.Header("Retry-After", strconv.Itoa(resetSeconds))
+			}
+		}
+		if err != nil {
+			utils.TooManyRequestsResponse(c, "Too many requests", err)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Attach only attaches the limiter of the client to the request, for routes
+// that charge their work as they resolve it.
+func (l *RateLimiter) Attach() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l.attach(c)
+		c.Next()
+	}
+}
+
+func (l *RateLimiter) attach(c *gin.Context) *ClientRateLimiter {
+	limiter := l.ForClient(ClientKey(c.Request.Context(), c.ClientIP()))
+	c.Request = c.Request.WithContext(domains.ContextWithRateLimiter(c.Request.Context(), limiter))
+	return limiter
+}
+
+// ForClient returns the limiter charging the buckets of client.
+func (l *RateLimiter) ForClient(client string) *ClientRateLimiter {
+	return &ClientRateLimiter{limiter: l, client: client}
+}
+
+// bucketTable returns the table a request is charged to. Tables the caller
+// cannot reach share the bucket of the empty name, so that made up names do
+// not create buckets.
+func (l *RateLimiter) bucketTable(ctx context.Context, tableName string) string {
+	if tableName == "" || l.items == nil || !l.items.IsTableReachable(ctx, tableName) {
+		return ""
+	}
+	return tableName
+}
+
+// ClientKey identifies the client of a request by its principal, falling back
+// to the IP address.
+func ClientKey(ctx context.Context, ip string) string {
+	if principal := domains.PrincipalFromContext(ctx); principal != nil && principal.Subject != "" {
+		return "sub:" + principal.Subject
+	}
+	return "ip:" + ip
+}
+
+// ClientRateLimiter charges the buckets of a single client.
+type ClientRateLimiter struct {
+	limiter *RateLimiter
+	client  string
+}
+
+type rateLimitStatus struct {
+	limit  config.RateLimit
+	result config.RateLimitResult
+}
+
+func (l *ClientRateLimiter) Take(ctx context.Context, tableName, operation string) error {
+	_, err := l.take(ctx, tableName, operation)
+	return err
+}
+
+// take takes a token and returns the state of the bucket, or nil when no
+// limit applies. Failures of the store are logged and let the request pass.
+func (l *ClientRateLimiter) take(ctx context.Context, tableName, operation string) (*rateLimitStatus, error) {
+	tableName = l.limiter.bucketTable(ctx, tableName)
+	limit := l.limiter.cfg.LimitFor(tableName, operation)
+	if !limit.Enabled() {
+		return nil, nil
+	}
+
+	result, err := l.limiter.store.Take(ctx, l.key(tableName, operation), limit)
+	if err != nil {
+		logrus.Errorf("rate limit store failed, allowing request: %v", err)
+		return nil, nil
+	}
+
+	status := &rateLimitStatus{limit: limit, result: result}
+	if !result.Allowed {
+		return status, &domains.Error{
+			Code:    domains.ErrCodeRateLimited,
+			Message: fmt.Sprintf("rate limit of %d requests per %s exceeded, retry in %s", limit.Requests, limit.Per, result.ResetAfter.Round(time.Second)),
+		}
+	}
+	return status, nil
+}
+
+func (l *ClientRateLimiter) Charge(ctx context.Context, tableName, operation string, cost int) {
+	tableName = l.limiter.bucketTable(ctx, tableName)
+	limit := l.limiter.cfg.LimitFor(tableName, operation)
+	if !limit.Enabled() || cost <= 0 {
+		return
+	}
+
+	if err := l.limiter.store.Charge(ctx, l.key(tableName, operation), limit, cost); err != nil {
+		logrus.Errorf("rate limit store failed to charge %d tokens: %v", cost, err)
+	}
+}
+
+func (l *ClientRateLimiter) key(tableName, operation string) string {
+	return fmt.Sprintf("%s|%s|%s", l.client, tableName, operation)
+}
+
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+	period   time.Duration
+}
+
+// MemoryRateLimitStore keeps token buckets in process memory.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit config.RateLimit) (config.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	refillRate := capacity / limit.Per.Seconds()
+
+	bucket := s.bucket(key, limit, now)
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / refillRate * float64(time.Second))
+		return config.RateLimitResult{Allowed: false, Remaining: 0, ResetAfter: wait}, nil
+	}
+
+	bucket.tokens--
+	untilFull := time.Duration((capacity - bucket.tokens) / refillRate * float64(time.Second))
+	return config.RateLimitResult{Allowed: true, Remaining: int(bucket.tokens), ResetAfter: untilFull}, nil
+}
+
+func (s *MemoryRateLimitStore) Charge(_ context.Context, key string, limit config.RateLimit, cost int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bucket(key, limit, s.now()).tokens -= float64(cost)
+	return nil
+}
+
+// bucket returns the bucket of key refilled up to now.
+func (s *MemoryRateLimitStore) bucket(key string, limit config.RateLimit, now time.Time) *tokenBucket {
+	capacity := float64(limit.Requests)
+	bucket, ok := s.buckets[key]
+	if !ok || bucket.capacity != capacity || bucket.period != limit.Per {
+		bucket = &tokenBucket{tokens: capacity, updated: now, capacity: capacity, period: limit.Per}
+		s.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(capacity, bucket.refilled(now))
+	bucket.updated = now
+	return bucket
+}
+
+// refilled returns the tokens of the bucket at now, ignoring its capacity.
+func (b *tokenBucket) refilled(now time.Time) float64 {
+	return b.tokens + now.Sub(b.updated).Seconds()*b.capacity/b.period.Seconds()
+}
+
+// sweep drops buckets that have refilled completely, they behave exactly like
+// missing ones.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if bucket.refilled(now) >= bucket.capacity {
+			delete(s.buckets, key)
+		}
+	}
+}
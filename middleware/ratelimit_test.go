@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	limit := config.RateLimit{Requests: 2, Per: time.Minute}
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		charge  int
+		allowed bool
+		remain  int
+	}{
+		{name: "first token", allowed: true, remain: 1},
+		{name: "second token", allowed: true, remain: 0},
+		{name: "empty bucket", allowed: false},
+		{name: "refilled one token", elapsed: 30 * time.Second, allowed: true, remain: 0},
+		{name: "debt after charge", elapsed: time.Minute, charge: 4, allowed: false},
+		{name: "debt refilled", elapsed: 90 * time.Second, allowed: true, remain: 0},
+	}
+
+	now := time.Unix(0, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.elapsed)
+			if tt.charge > 0 {
+				if err := store.Charge(context.Background(), "key", limit, tt.charge); err != nil {
+					t.Fatalf("Charge() = %v", err)
+				}
+			}
+
+			result, err := store.Take(context.Background(), "key", limit)
+			if err != nil {
+				t.Fatalf("Take() = %v", err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Fatalf("Take() allowed = %v, want %v", result.Allowed, tt.allowed)
+			}
+			if tt.allowed && result.Remaining != tt.remain {
+				t.Errorf("Take() remaining = %d, want %d", result.Remaining, tt.remain)
+			}
+			if !tt.allowed && result.ResetAfter <= 0 {
+				t.Errorf("Take() reset after = %s, want a wait", result.ResetAfter)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	limit := config.RateLimit{Requests: 1, Per: time.Minute}
+
+	now := time.Unix(0, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	store.lastSweep = now
+
+	if _, err := store.Take(context.Background(), "full", limit); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Charge(context.Background(), "debt", limit, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	store.sweep(now)
+
+	if _, ok := store.buckets["full"]; ok {
+		t.Error("refilled bucket was not swept")
+	}
+	if _, ok := store.buckets["debt"]; !ok {
+		t.Error("bucket in debt was swept")
+	}
+}
+
+func TestClientRateLimiterBucketTable(t *testing.T) {
+	limiter := &RateLimiter{
+		cfg: &config.RateLimitConfig{
+			Tables: map[string]map[string]config.RateLimit{
+				"": {config.OperationRead: {Requests: 1, Per: time.Minute}},
+			},
+		},
+		store: NewMemoryRateLimitStore(),
+	}
+	client := limiter.ForClient("ip:127.0.0.1")
+	ctx := context.Background()
+
+	// Without a way to check them, every table name is charged to the shared
+	// bucket, so made up names cannot create buckets.
+	if err := client.Take(ctx, "no_such_table", config.OperationRead); err != nil {
+		t.Fatalf("Take() = %v, want nil", err)
+	}
+	err := client.Take(ctx, "another_table", config.OperationRead)
+	var domainErr *domains.Error
+	if !errors.As(err, &domainErr) || domainErr.Code != domains.ErrCodeRateLimited {
+		t.Fatalf("Take() = %v, want rate_limited", err)
+	}
+
+	store := limiter.store.(*MemoryRateLimitStore)
+	if len(store.buckets) != 1 {
+		t.Errorf("buckets = %d, want 1", len(store.buckets))
+	}
+}
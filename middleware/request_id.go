@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID propagates the X-Request-ID header, generating one when the
+// client did not send it, and makes it available to the service layer.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, requestID := RequestContext(c.Request)
+
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequestContext returns the context of r carrying its request ID, taken
+// from the X-Request-ID header or generated.
+func RequestContext(r *http.Request) (context.Context, string) {
+	requestID := r.Header.Get(RequestIDHeader)
+	if requestID == "" || len(requestID) > 128 {
+		requestID = newRequestID()
+	}
+	return domains.ContextWithRequestID(r.Context(), requestID), requestID
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
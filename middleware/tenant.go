@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var schemaNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,62}$`)
+
+// TenantError is returned by a TenantResolver for requests whose tenant
+// cannot be resolved. Message describes the failure to clients.
+type TenantError struct {
+	Message string
+	Err     error
+}
+
+func (e *TenantError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *TenantError) Unwrap() error {
+	return e.Err
+}
+
+// TenantResolver returns the context of a request scoped to its tenant.
+type TenantResolver func(r *http.Request) (context.Context, error)
+
+// ResolveTenant builds the tenant middleware of a resolver.
+func ResolveTenant(resolve TenantResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, err := resolve(c.Request)
+		if err != nil {
+			var tenantErr *TenantError
+			if !errors.As(err, &tenantErr) {
+				tenantErr = &TenantError{Message: "Failed to resolve tenant", Err: err}
+			}
+			if tenantErr.Err != nil {
+				logrus.Warnf("tenant resolution failed: %v", tenantErr.Err)
+			}
+			utils.BadRequestResponse(c, tenantErr.Message, tenantErr.Err)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// NewTenantResolver builds the tenant resolver described by cfg.Tenancy. It
+// returns nil when tenancy is not configured. Resolution failures are
+// reported as *TenantError.
+func NewTenantResolver(cfg *config.GenApiConfig) (TenantResolver, error) {
+	if cfg.Tenancy == nil {
+		return nil, nil
+	}
+
+	tenancy := cfg.Tenancy
+	if tenancy.Resolver == nil && tenancy.Claim == "" && tenancy.Header == "" && !tenancy.Subdomain {
+		return nil, errors.New("tenancy config requires a resolver, a claim, a header or subdomain resolution")
+	}
+
+	return func(r *http.Request) (context.Context, error) {
+		tenant, err := resolveTenant(tenancy, r)
+		if err != nil {
+			return nil, &TenantError{Message: "Failed to resolve tenant", Err: err}
+		}
+		if tenant == "" {
+			return nil, &TenantError{Message: "Tenant is required"}
+		}
+
+		ctx := domains.ContextWithTenant(r.Context(), tenant)
+		if tenancy.SchemaPerTenant {
+			schema := tenancy.GetSchema(tenant)
+			if !schemaNamePattern.MatchString(schema) || strings.HasPrefix(schema, "pg_") || schema == "information_schema" {
+				return nil, &TenantError{Message: "Invalid tenant", Err: fmt.Errorf("tenant %q does not map to a valid schema name", tenant)}
+			}
+			ctx = domains.ContextWithSchema(ctx, schema)
+		}
+		return ctx, nil
+	}, nil
+}
+
+func resolveTenant(tenancy *config.TenancyConfig, r *http.Request) (string, error) {
+	if tenancy.Resolver != nil {
+		return tenancy.Resolver(r)
+	}
+
+	if tenancy.Claim != "" {
+		var claim any
+		if principal := domains.PrincipalFromContext(r.Context()); principal != nil {
+			claim = principal.Claims[tenancy.Claim]
+		}
+		switch value := claim.(type) {
+		case nil:
+		case string:
+			if value != "" {
+				return value, nil
+			}
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64), nil
+		case int, int64:
+			return fmt.Sprint(value), nil
+		default:
+			return "", fmt.Errorf("claim %s must be a string or a number", tenancy.Claim)
+		}
+		if !tenancy.ClaimFallback {
+			return "", fmt.Errorf("claim %s is missing", tenancy.Claim)
+		}
+	}
+
+	if tenancy.Header != "" {
+		if value := strings.TrimSpace(r.Header.Get(tenancy.Header)); value != "" {
+			return value, nil
+		}
+	}
+
+	if tenancy.Subdomain {
+		host := r.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		if labels := strings.Split(host, "."); len(labels) > 2 && net.ParseIP(host) == nil {
+			return labels[0], nil
+		}
+	}
+
+	return "", nil
+}
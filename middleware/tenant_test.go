@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveTenant(t *testing.T) {
+	tests := []struct {
+		name      string
+		tenancy   config.TenancyConfig
+		principal *domains.Principal
+		header    string
+		host      string
+		want      string
+		wantErr   bool
+	}{
+		{name: "string claim", tenancy: config.TenancyConfig{Claim: "tenant_id"}, principal: &domains.Principal{Claims: map[string]any{"tenant_id": "acme"}}, want: "acme"},
+		{name: "number claim", tenancy: config.TenancyConfig{Claim: "tenant_id"}, principal: &domains.Principal{Claims: map[string]any{"tenant_id": float64(42)}}, want: "42"},
+		{name: "object claim", tenancy: config.TenancyConfig{Claim: "tenant_id"}, principal: &domains.Principal{Claims: map[string]any{"tenant_id": map[string]any{}}}, wantErr: true},
+		{name: "claim wins over header", tenancy: config.TenancyConfig{Claim: "tenant_id", Header: "X-Tenant"}, principal: &domains.Principal{Claims: map[string]any{"tenant_id": "acme"}}, header: "other", want: "acme"},
+		{name: "missing claim does not fall back", tenancy: config.TenancyConfig{Claim: "tenant_id", Header: "X-Tenant"}, principal: &domains.Principal{}, header: "other", wantErr: true},
+		{name: "anonymous does not fall back", tenancy: config.TenancyConfig{Claim: "tenant_id", Subdomain: true}, host: "other.example.com", wantErr: true},
+		{name: "empty claim does not fall back", tenancy: config.TenancyConfig{Claim: "tenant_id", Header: "X-Tenant"}, principal: &domains.Principal{Claims: map[string]any{"tenant_id": ""}}, header: "other", wantErr: true},
+		{name: "missing claim falls back when enabled", tenancy: config.TenancyConfig{Claim: "tenant_id", ClaimFallback: true, Header: "X-Tenant"}, principal: &domains.Principal{}, header: "other", want: "other"},
+		{name: "header", tenancy: config.TenancyConfig{Header: "X-Tenant"}, header: " acme ", want: "acme"},
+		{name: "subdomain", tenancy: config.TenancyConfig{Subdomain: true}, host: "acme.example.com:8080", want: "acme"},
+		{name: "no subdomain", tenancy: config.TenancyConfig{Subdomain: true}, host: "example.com"},
+		{name: "ip address", tenancy: config.TenancyConfig{Subdomain: true}, host: "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/items/users", nil)
+			if tt.principal != nil {
+				r = r.WithContext(domains.ContextWithPrincipal(r.Context(), tt.principal))
+			}
+			if tt.header != "" {
+				r.Header.Set("X-Tenant", tt.header)
+			}
+			if tt.host != "" {
+				r.Host = tt.host
+			}
+
+			tenant, err := resolveTenant(&tt.tenancy, r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveTenant() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tenant != tt.want {
+				t.Errorf("resolveTenant() = %q, want %q", tenant, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/repository/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+)
+
+type APIKeyRepository struct {
+	db    *db.DB
+	table string
+}
+
+func NewAPIKeyRepository(db *db.DB, table string) *APIKeyRepository {
+	return &APIKeyRepository{db: db, table: pgx.Identifier{table}.Sanitize()}
+}
+
+const createAPIKeyTableQuery = `
+CREATE TABLE IF NOT EXISTS %s (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL UNIQUE,
+    secret_hash TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT '',
+    allowed_tables TEXT[] NOT NULL DEFAULT '{}',
+    allowed_operations TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+)
+`
+
+const apiKeyColumns = `id, name, prefix, secret_hash, role, allowed_tables, allowed_operations,
+    expires_at, last_used_at, created_at, revoked_at`
+
+// EnsureTable creates the key table when it does not exist yet.
+func (r *APIKeyRepository) EnsureTable(ctx context.Context) error {
+	if _, err := r.db.Pool.Exec(ctx, fmt.Sprintf(createAPIKeyTableQuery, r.table)); err != nil {
+		logrus.Errorf("failed to create api key table: %v", err)
+		return fmt.Errorf("failed to create api key table: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domains.APIKey) (*domains.APIKey, error) {
+	query := fmt.Sprintf(`
+INSERT INTO %s (name, prefix, secret_hash, role, allowed_tables, allowed_operations, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING %s`, r.table, apiKeyColumns)
+
+	row := r.db.Pool.QueryRow(ctx, query,
+		key.Name, key.Prefix, key.SecretHash, key.Role,
+		nonNilStrings(key.AllowedTables), nonNilStrings(key.AllowedOperations), key.ExpiresAt)
+
+	created, err := scanAPIKey(row)
+	if err != nil {
+		logrus.Errorf("failed to create api key: %v", err)
+		return nil, translateError(err, "create api key")
+	}
+	return created, nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]domains.APIKey, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY id", apiKeyColumns, r.table)
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		logrus.Errorf("failed to list api keys: %v", err)
+		return nil, translateError(err, "list api keys")
+	}
+	defer rows.Close()
+
+	keys := []domains.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			logrus.Errorf("failed to scan api key: %v", err)
+			return nil, translateError(err, "list api keys")
+		}
+		keys = append(keys, *key)
+	}
+
+	if err = rows.Err(); err != nil {
+		logrus.Errorf("rows iteration error for api keys: %v", err)
+		return nil, translateError(err, "list api keys")
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domains.APIKey, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE prefix = $1", apiKeyColumns, r.table)
+
+	key, err := scanAPIKey(r.db.Pool.QueryRow(ctx, query, prefix))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domains.NewError(domains.ErrCodeNotFound, "api key not found")
+		}
+		logrus.Errorf("failed to get api key: %v", err)
+		return nil, translateError(err, "get api key")
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", r.table)
+
+	result, err := r.db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		logrus.Errorf("failed to revoke api key: %v", err)
+		return translateError(err, "revoke api key")
+	}
+	if result.RowsAffected() == 0 {
+		return domains.NewError(domains.ErrCodeNotFound, "api key not found")
+	}
+	return nil
+}
+
+// TouchLastUsed records the use of a key. Writes are throttled to one per
+// minute per key to keep authentication cheap.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	query := fmt.Sprintf(`UPDATE %s SET last_used_at = now()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')`, r.table)
+
+	if _, err := r.db.Pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to update api key usage: %w", err)
+	}
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*domains.APIKey, error) {
+	var key domains.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.SecretHash,
+		&key.Role,
+		&key.AllowedTables,
+		&key.AllowedOperations,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
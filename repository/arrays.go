@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgtype"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// builtinTypes knows the types pgx decodes, arrays of other element types
+// such as enums are read as text[].
+var builtinTypes = pgtype.NewMap()
+
+// arrayElementTypes maps the internal names the schema uses for the elements
+// of arrays to the names of the types of other columns.
+var arrayElementTypes = map[string]string{
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"time":        "time without time zone",
+}
+
+var errRaggedArray = errors.New("must have sub-arrays of the same length")
+
+// arrayElementType returns the element type of an array column type.
+func arrayElementType(dataType string) string {
+	elementType := strings.TrimSuffix(dataType, "[]")
+	if name, ok := arrayElementTypes[elementType]; ok {
+		return name
+	}
+	return elementType
+}
+
+// isArrayType reports whether values of the column type are arrays.
+func isArrayType(dataType string) bool {
+	return strings.HasSuffix(dataType, "[]")
+}
+
+// isJSONType reports whether values of the type are JSON documents, in which
+// lists are values and not dimensions of an array.
+func isJSONType(dataType string) bool {
+	return dataType == "json" || dataType == "jsonb"
+}
+
+// arrayValue converts an array scanned by pgx to a list, with one level of
+// nesting per dimension and the elements converted like column values. NULL
+// elements are nil.
+func (r *ItemRepository) arrayValue(array pgtype.Array[any], dataType string) any {
+	if !array.Valid {
+		return nil
+	}
+
+	elementType := arrayElementType(dataType)
+	elements := make([]any, len(array.Elements))
+	for i, element := range array.Elements {
+		elements[i] = r.columnValue(element, elementType)
+	}
+	if len(elements) == 0 {
+		return []any{}
+	}
+	return nestArray(elements, array.Dims)
+}
+
+func nestArray(elements []any, dims []pgtype.ArrayDimension) []any {
+	if len(dims) <= 1 {
+		return elements
+	}
+	nested := make([]any, dims[0].Length)
+	size := len(elements) / len(nested)
+	for i := range nested {
+		nested[i] = nestArray(elements[i*size:(i+1)*size], dims[1:])
+	}
+	return nested
+}
+
+// flattenArray returns the dimensions and the elements of a list given for an
+// array column. Nested lists are the dimensions of multidimensional arrays,
+// except in arrays of JSON.
+func flattenArray(value []any, dataType string) ([]pgtype.ArrayDimension, []any, error) {
+	isJSON := isJSONType(arrayElementType(dataType))
+
+	var dims []pgtype.ArrayDimension
+	for level := value; ; {
+		dims = append(dims, pgtype.ArrayDimension{Length: int32(len(level)), LowerBound: 1})
+		if len(level) == 0 {
+			break
+		}
+		nested, ok := level[0].([]any)
+		if !ok || isJSON {
+			break
+		}
+		level = nested
+	}
+
+	var elements []any
+	var flatten func(level []any, depth int) error
+	flatten = func(level []any, depth int) error {
+		if int32(len(level)) != dims[depth].Length {
+			return errRaggedArray
+		}
+		for _, element := range level {
+			nested, isList := element.([]any)
+			if isJSON {
+				isList = false
+			}
+			switch {
+			case depth < len(dims)-1 && isList:
+				if err := flatten(nested, depth+1); err != nil {
+					return err
+				}
+			case depth < len(dims)-1 || isList:
+				return errRaggedArray
+			default:
+				elements = append(elements, element)
+			}
+		}
+		return nil
+	}
+	if err := flatten(value, 0); err != nil {
+		return nil, nil, err
+	}
+
+	if len(elements) == 0 {
+		return nil, nil, nil
+	}
+	return dims, elements, nil
+}
+
+// arrayLiteral writes a list given for an array column as an array literal.
+// PostgreSQL parses the elements as values of the element type, which holds
+// for every element type including enums and domains.
+func arrayLiteral(value []any, dataType string) (string, error) {
+	dims, elements, err := flattenArray(value, dataType)
+	if err != nil {
+		return "", err
+	}
+
+	isJSON := isJSONType(arrayElementType(dataType))
+	texts := make([]string, len(elements))
+	for i, element := range elements {
+		text, err := arrayElementLiteral(element, isJSON)
+		if err != nil {
+			return "", fmt.Errorf("element %d %w", i, err)
+		}
+		texts[i] = text
+	}
+
+	var literal strings.Builder
+	writeArrayLiteral(&literal, texts, dims)
+	return literal.String(), nil
+}
+
+func writeArrayLiteral(literal *strings.Builder, texts []string, dims []pgtype.ArrayDimension) {
+	literal.WriteByte('{')
+	if len(dims) > 1 {
+		size := len(texts) / int(dims[0].Length)
+		for i := 0; i < int(dims[0].Length); i++ {
+			if i > 0 {
+				literal.WriteByte(',')
+			}
+			writeArrayLiteral(literal, texts[i*size:(i+1)*size], dims[1:])
+		}
+	} else {
+		literal.WriteString(strings.Join(texts, ","))
+	}
+	literal.WriteByte('}')
+}
+
+// arrayElementLiteral quotes an element of an array literal, NULL elements
+// are left unquoted.
+func arrayElementLiteral(element any, isJSON bool) (string, error) {
+	if element == nil {
+		return "NULL", nil
+	}
+
+	var text string
+	switch v := element.(type) {
+	case string:
+		text = v
+	case json.Number:
+		text = v.String()
+	case bool:
+		text = strconv.FormatBool(v)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		text = v.Format(time.RFC3339Nano)
+	default:
+		text = fmt.Sprint(v)
+	}
+	if isJSON {
+		encoded, err := json.Marshal(element)
+		if err != nil {
+			return "", fmt.Errorf("must be valid JSON")
+		}
+		text = string(encoded)
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`, nil
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func dims(lengths ...int32) []pgtype.ArrayDimension {
+	dimensions := make([]pgtype.ArrayDimension, len(lengths))
+	for i, length := range lengths {
+		dimensions[i] = pgtype.ArrayDimension{Length: length, LowerBound: 1}
+	}
+	return dimensions
+}
+
+func TestFlattenArray(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        []any
+		dataType     string
+		wantDims     []pgtype.ArrayDimension
+		wantElements []any
+		wantErr      error
+	}{
+		{name: "empty", value: []any{}, dataType: "integer[]"},
+		{name: "empty sub-arrays", value: []any{[]any{}, []any{}}, dataType: "integer[]"},
+		{name: "one dimension", value: []any{float64(1), nil, float64(3)}, dataType: "integer[]", wantDims: dims(3), wantElements: []any{float64(1), nil, float64(3)}},
+		{name: "two dimensions", value: []any{[]any{"a", "b"}, []any{"c", nil}}, dataType: "text[]", wantDims: dims(2, 2), wantElements: []any{"a", "b", "c", nil}},
+		{name: "three dimensions", value: []any{[]any{[]any{true}}, []any{[]any{false}}}, dataType: "boolean[]", wantDims: dims(2, 1, 1), wantElements: []any{true, false}},
+		{name: "ragged sub-arrays", value: []any{[]any{"a", "b"}, []any{"c"}}, dataType: "text[]", wantErr: errRaggedArray},
+		{name: "element after sub-array", value: []any{[]any{"a"}, "b"}, dataType: "text[]", wantErr: errRaggedArray},
+		{name: "sub-array after element", value: []any{"a", []any{"b"}}, dataType: "text[]", wantErr: errRaggedArray},
+		{name: "empty sub-array after sub-array", value: []any{[]any{"a"}, []any{}}, dataType: "text[]", wantErr: errRaggedArray},
+		{
+			name:         "jsonb with nested lists",
+			value:        []any{[]any{float64(1), []any{"a"}}, map[string]any{"b": []any{}}},
+			dataType:     "jsonb[]",
+			wantDims:     dims(2),
+			wantElements: []any{[]any{float64(1), []any{"a"}}, map[string]any{"b": []any{}}},
+		},
+		{name: "json of one list", value: []any{[]any{"a"}}, dataType: "json[]", wantDims: dims(1), wantElements: []any{[]any{"a"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotDims, gotElements, err := flattenArray(tt.value, tt.dataType)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("flattenArray() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotDims, tt.wantDims) || !reflect.DeepEqual(gotElements, tt.wantElements) {
+				t.Errorf("flattenArray() = %v, %v, want %v, %v", gotDims, gotElements, tt.wantDims, tt.wantElements)
+			}
+		})
+	}
+}
+
+func TestNestArray(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements []any
+		dims     []pgtype.ArrayDimension
+		want     []any
+	}{
+		{name: "no dimensions", elements: []any{"a"}, want: []any{"a"}},
+		{name: "one dimension", elements: []any{"a", "b"}, dims: dims(2), want: []any{"a", "b"}},
+		{name: "two dimensions", elements: []any{"a", "b", "c", nil, "e", "f"}, dims: dims(2, 3), want: []any{[]any{"a", "b", "c"}, []any{nil, "e", "f"}}},
+		{name: "three dimensions", elements: []any{1, 2, 3, 4}, dims: dims(2, 1, 2), want: []any{[]any{[]any{1, 2}}, []any{[]any{3, 4}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nestArray(tt.elements, tt.dims); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nestArray() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlattenArrayNestArray(t *testing.T) {
+	value := []any{[]any{"a", "b", "c"}, []any{"d", nil, "f"}}
+	dimensions, elements, err := flattenArray(value, "text[]")
+	if err != nil {
+		t.Fatalf("flattenArray() error = %v", err)
+	}
+	if got := nestArray(elements, dimensions); !reflect.DeepEqual(got, value) {
+		t.Errorf("nestArray(flattenArray()) = %v, want %v", got, value)
+	}
+}
+
+func TestArrayElementLiteral(t *testing.T) {
+	tests := []struct {
+		name    string
+		element any
+		isJSON  bool
+		want    string
+		wantErr bool
+	}{
+		{name: "null", element: nil, want: `NULL`},
+		{name: "null text", element: "NULL", want: `"NULL"`},
+		{name: "empty string", element: "", want: `""`},
+		{name: "quote", element: `say "hi"`, want: `"say \"hi\""`},
+		{name: "backslash", element: `C:\dir`, want: `"C:\\dir"`},
+		{name: "separators", element: "{a, b}", want: `"{a, b}"`},
+		{name: "number", element: 1.5, want: `"1.5"`},
+		{name: "large number", element: 1e21, want: `"1000000000000000000000"`},
+		{name: "json number", element: json.Number("12345678901234567890"), want: `"12345678901234567890"`},
+		{name: "boolean", element: true, want: `"true"`},
+		{name: "time", element: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), want: `"2026-01-02T03:04:05Z"`},
+		{name: "json object", element: map[string]any{"a": `"x"`}, isJSON: true, want: `"{\"a\":\"\\\"x\\\"\"}"`},
+		{name: "json list", element: []any{float64(1), "b"}, isJSON: true, want: `"[1,\"b\"]"`},
+		{name: "json string", element: "a", isJSON: true, want: `"\"a\""`},
+		{name: "json null element", element: nil, isJSON: true, want: `NULL`},
+		{name: "invalid json", element: math.NaN(), isJSON: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := arrayElementLiteral(tt.element, tt.isJSON)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("arrayElementLiteral() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("arrayElementLiteral() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrayLiteral(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    []any
+		dataType string
+		want     string
+		wantErr  bool
+	}{
+		{name: "empty", value: []any{}, dataType: "text[]", want: `{}`},
+		{name: "one dimension", value: []any{"a", nil, ""}, dataType: "text[]", want: `{"a",NULL,""}`},
+		{name: "two dimensions", value: []any{[]any{float64(1), float64(2)}, []any{float64(3), nil}}, dataType: "integer[]", want: `{{"1","2"},{"3",NULL}}`},
+		{name: "enum", value: []any{"happy", "sad"}, dataType: "mood[]", want: `{"happy","sad"}`},
+		{name: "jsonb", value: []any{[]any{"a"}, map[string]any{"b": nil}}, dataType: "jsonb[]", want: `{"[\"a\"]","{\"b\":null}"}`},
+		{name: "ragged", value: []any{[]any{"a"}, []any{"b", "c"}}, dataType: "text[]", wantErr: true},
+		{name: "invalid json element", value: []any{math.Inf(1)}, dataType: "json[]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := arrayLiteral(tt.value, tt.dataType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("arrayLiteral() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("arrayLiteral() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrayValue(t *testing.T) {
+	r := &ItemRepository{cfg: &config.GenApiConfig{}}
+
+	tests := []struct {
+		name     string
+		array    pgtype.Array[any]
+		dataType string
+		want     any
+	}{
+		{name: "null", array: pgtype.Array[any]{}, dataType: "integer[]", want: nil},
+		{name: "empty", array: pgtype.Array[any]{Valid: true}, dataType: "integer[]", want: []any{}},
+		{name: "integers", array: pgtype.Array[any]{Elements: []any{int32(1), nil}, Dims: dims(2), Valid: true}, dataType: "integer[]", want: []any{int64(1), nil}},
+		{
+			name:     "two dimensions",
+			array:    pgtype.Array[any]{Elements: []any{int16(1), int16(2), int16(3), int16(4)}, Dims: dims(2, 2), Valid: true},
+			dataType: "smallint[]",
+			want:     []any{[]any{int64(1), int64(2)}, []any{int64(3), int64(4)}},
+		},
+		{name: "enum read as text", array: pgtype.Array[any]{Elements: []any{"happy", nil}, Dims: dims(2), Valid: true}, dataType: "mood[]", want: []any{"happy", nil}},
+		{
+			name:     "timestamps",
+			array:    pgtype.Array[any]{Elements: []any{time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}, Dims: dims(1), Valid: true},
+			dataType: "timestamp[]",
+			want:     []any{"2026-01-02T03:04:05"},
+		},
+		{
+			name:     "jsonb with lists",
+			array:    pgtype.Array[any]{Elements: []any{[]any{"a"}, map[string]any{"b": float64(1)}}, Dims: dims(2), Valid: true},
+			dataType: "jsonb[]",
+			want:     []any{[]any{"a"}, map[string]any{"b": float64(1)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.arrayValue(tt.array, tt.dataType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("arrayValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Elements of a bigint[] beyond 2^53 are written with every digit and read
+// back as int64 values, which JSON encodes exactly.
+func TestBigIntArrayRoundTrip(t *testing.T) {
+	r := &ItemRepository{cfg: &config.GenApiConfig{}}
+	columnTypes := map[string]string{"ids": "bigint[]"}
+
+	param, err := columnParam("ids", []any{[]any{json.Number("9007199254740993"), nil}, []any{json.Number("-9007199254740993"), json.Number("1")}}, columnTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{{"9007199254740993",NULL},{"-9007199254740993","1"}}`; param != want {
+		t.Errorf("columnParam() = %v, want %s", param, want)
+	}
+
+	stored := pgtype.Array[any]{Elements: []any{int64(9007199254740993), nil, int64(-9007199254740993), int64(1)}, Dims: dims(2, 2), Valid: true}
+	encoded, err := json.Marshal(r.arrayValue(stored, "bigint[]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[[9007199254740993,null],[-9007199254740993,1]]`; string(encoded) != want {
+		t.Errorf("read back %s, want %s", encoded, want)
+	}
+}
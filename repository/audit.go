@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+)
+
+// writtenAlias names the CTE holding the rows touched by an audited write.
+const writtenAlias = "written"
+
+// previousAlias names the CTE holding the row version an update replaces.
+const previousAlias = "previous"
+
+// auditInsert builds the INSERT recording every row of the written CTE in the
+// audit table, so that the entry commits or rolls back with the write itself.
+// Its placeholders are numbered from argIndex. Hidden columns are never
+// copied into the audit table, which always lives in the default schema.
+func (r *ItemRepository) auditInsert(ctx context.Context, tableName, pkColumn, operation string, argIndex int) (string, []any) {
+	hiddenParam := fmt.Sprintf("$%d::text[]", argIndex)
+	currentRow := fmt.Sprintf("to_jsonb(%s) - %s", writtenAlias, hiddenParam)
+
+	oldRow, newRow := "NULL", currentRow
+	switch operation {
+	case domains.AuditOperationUpdate:
+		oldRow = fmt.Sprintf("(SELECT row_data FROM %s) - %s", previousAlias, hiddenParam)
+	case domains.AuditOperationDelete:
+		oldRow, newRow = currentRow, "NULL"
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (actor, table_name, row_pk, operation, old_row, new_row, request_id)
+SELECT $%d::text, $%d::text, %s.%s::text, $%d::text, %s, %s, $%d::text FROM %s`,
+		auditTable(r.cfg.Audit.GetTable()),
+		argIndex+1, argIndex+2, writtenAlias, r.quoteIdentifier(pkColumn), argIndex+3,
+		oldRow, newRow, argIndex+4, writtenAlias)
+
+	var actor string
+	if principal := domains.PrincipalFromContext(ctx); principal != nil {
+		actor = principal.Subject
+	}
+
+	args := []any{
+		r.cfg.HiddenColumns(tableName),
+		nullIfEmpty(actor),
+		auditedTableName(ctx, tableName),
+		operation,
+		nullIfEmpty(domains.RequestIDFromContext(ctx)),
+	}
+	return query, args
+}
+
+func nullIfEmpty(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/repository/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditRepository struct {
+	db               *db.DB
+	table            string
+	index            string
+	rowLevelSecurity bool
+}
+
+// NewAuditRepository reads and writes the audit table, which always lives in
+// the default schema whatever the schema of the request. With rowLevelSecurity,
+// the request roles are allowed to add entries.
+func NewAuditRepository(db *db.DB, table string, rowLevelSecurity bool) *AuditRepository {
+	return &AuditRepository{
+		db:               db,
+		table:            auditTable(table),
+		index:            pgx.Identifier{table + "_lookup_idx"}.Sanitize(),
+		rowLevelSecurity: rowLevelSecurity,
+	}
+}
+
+// auditTable is the schema-qualified name of the audit table.
+func auditTable(table string) string {
+	return pgx.Identifier{db.DefaultSchema, table}.Sanitize()
+}
+
+const createAuditTableQuery = `
+CREATE TABLE IF NOT EXISTS %[1]s (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT,
+    table_name TEXT NOT NULL,
+    row_pk TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    old_row JSONB,
+    new_row JSONB,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (table_name, row_pk, created_at)
+`
+
+// grantAuditInsertQuery lets every role add audit entries, since entries are
+// written in the transaction of the request after its role is assumed. Row
+// level security without a SELECT policy keeps the entries hidden from them.
+const grantAuditInsertQuery = `
+ALTER TABLE %[1]s ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS genapi_audit_insert ON %[1]s;
+CREATE POLICY genapi_audit_insert ON %[1]s FOR INSERT WITH CHECK (true);
+GRANT INSERT ON %[1]s TO PUBLIC;
+GRANT USAGE ON SEQUENCE %[2]s TO PUBLIC
+`
+
+// EnsureTable creates the audit table when it does not exist yet. With row
+// level security, it also grants the request roles what writing entries
+// takes.
+func (r *AuditRepository) EnsureTable(ctx context.Context) error {
+	if _, err := r.db.Pool.Exec(ctx, fmt.Sprintf(createAuditTableQuery, r.table, r.index)); err != nil {
+		logrus.Errorf("failed to create audit table: %v", err)
+		return fmt.Errorf("failed to create audit table: %w", err)
+	}
+	if !r.rowLevelSecurity {
+		return nil
+	}
+
+	var sequence string
+	if err := r.db.Pool.QueryRow(ctx, "SELECT pg_get_serial_sequence($1, 'id')", r.table).Scan(&sequence); err != nil {
+		logrus.Errorf("failed to get audit table sequence: %v", err)
+		return fmt.Errorf("failed to get audit table sequence: %w", err)
+	}
+	if _, err := r.db.Pool.Exec(ctx, r.grantInsertQuery(sequence)); err != nil {
+		logrus.Errorf("failed to grant access to audit table: %v", err)
+		return fmt.Errorf("failed to grant access to audit table: %w", err)
+	}
+	return nil
+}
+
+// grantInsertQuery grants adding entries to the audit table, whose id is
+// generated by sequence.
+func (r *AuditRepository) grantInsertQuery(sequence string) string {
+	return fmt.Sprintf(grantAuditInsertQuery, r.table, sequence)
+}
+
+const getAuditHistoryQuery = `
+SELECT id, actor, table_name, row_pk, operation, old_row, new_row, request_id, created_at
+FROM %s
+WHERE table_name = $1 AND row_pk = $2%s
+ORDER BY created_at, id`
+
+// GetHistory returns the audit entries of a single row, oldest first. With
+// a tenant scope, only entries of rows belonging to the tenant are returned.
+func (r *AuditRepository) GetHistory(ctx context.Context, tableName, primaryKey string, scope *domains.TenantScope) ([]domains.AuditEntry, error) {
+	args := []any{auditedTableName(ctx, tableName), primaryKey}
+	var tenantCondition string
+	if scope != nil {
+		tenantCondition = " AND COALESCE(new_row, old_row) ->> $3 = $4"
+		args = append(args, scope.Column, scope.Value)
+	}
+
+	rows, err := r.db.Pool.Query(ctx, fmt.Sprintf(getAuditHistoryQuery, r.table, tenantCondition), args...)
+	if err != nil {
+		logrus.Errorf("failed to get audit history: %v", err)
+		return nil, translateError(err, "get audit history")
+	}
+	defer rows.Close()
+
+	entries := []domains.AuditEntry{}
+	for rows.Next() {
+		var entry domains.AuditEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.TableName,
+			&entry.PrimaryKey,
+			&entry.Operation,
+			&entry.OldRow,
+			&entry.NewRow,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			logrus.Errorf("failed to scan audit entry: %v", err)
+			return nil, translateError(err, "get audit history")
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		logrus.Errorf("rows iteration error for audit history: %v", err)
+		return nil, translateError(err, "get audit history")
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"strings"
+	"testing"
+)
+
+func TestAuditTable(t *testing.T) {
+	ctx := domains.ContextWithSchema(context.Background(), "tenant_acme")
+	audit := NewAuditRepository(nil, "audit log", true)
+	items := &ItemRepository{cfg: &config.GenApiConfig{Audit: &config.AuditConfig{Table: "audit log"}}}
+
+	want := `"public"."audit log"`
+	if audit.table != want {
+		t.Errorf("audit table read from %s, want %s", audit.table, want)
+	}
+	if query, _ := items.auditInsert(ctx, "users", "id", domains.AuditOperationCreate, 1); !strings.HasPrefix(query, "INSERT INTO "+want+" ") {
+		t.Errorf("audit entries written by %s, want INSERT INTO %s", query, want)
+	}
+}
+
+func TestAuditGrantInsertQuery(t *testing.T) {
+	query := NewAuditRepository(nil, "genapi_audit_log", true).grantInsertQuery("public.genapi_audit_log_id_seq")
+
+	for _, statement := range []string{
+		`ALTER TABLE "public"."genapi_audit_log" ENABLE ROW LEVEL SECURITY`,
+		`CREATE POLICY genapi_audit_insert ON "public"."genapi_audit_log" FOR INSERT WITH CHECK (true)`,
+		`GRANT INSERT ON "public"."genapi_audit_log" TO PUBLIC`,
+		`GRANT USAGE ON SEQUENCE public.genapi_audit_log_id_seq TO PUBLIC`,
+	} {
+		if !strings.Contains(query, statement) {
+			t.Errorf("grant query lacks %q:\n%s", statement, query)
+		}
+	}
+	if strings.Contains(query, "GRANT SELECT") || strings.Contains(query, "FOR SELECT") {
+		t.Errorf("grant query lets request roles read the audit table:\n%s", query)
+	}
+}
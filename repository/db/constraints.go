@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const GetForeignKeysQuery = `
+SELECT
+    c.conname::text,
+    ARRAY(
+        SELECT a.attname::text
+        FROM unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord)
+        JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+        ORDER BY k.ord
+    ),
+    rt.relname::text,
+    ARRAY(
+        SELECT a.attname::text
+        FROM unnest(c.confkey) WITH ORDINALITY AS k(attnum, ord)
+        JOIN pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum
+        ORDER BY k.ord
+    )
+FROM pg_constraint c
+JOIN pg_class t ON t.oid = c.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_class rt ON rt.oid = c.confrelid
+WHERE c.contype = 'f' AND t.relname = $1 AND n.nspname = $2
+ORDER BY c.conname
+`
+
+func (db *DB) getForeignKeys(ctx context.Context, tableName string) ([]domains.ForeignKey, error) {
+	rows, err := db.Pool.Query(ctx, GetForeignKeysQuery, tableName, SchemaFromContext(ctx))
+	if err != nil {
+		logrus.Errorf("failed to get foreign keys: %v", err)
+		return nil, fmt.Errorf("failed to get foreign keys: %w", err)
+	}
+
+	foreignKeys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domains.ForeignKey, error) {
+		var foreignKey domains.ForeignKey
+		err := row.Scan(&foreignKey.Name, &foreignKey.Columns, &foreignKey.ReferencedTable, &foreignKey.ReferencedColumns)
+		return foreignKey, err
+	})
+	if err != nil {
+		logrus.Errorf("failed to scan foreign keys: %v", err)
+		return nil, fmt.Errorf("failed to scan foreign keys: %w", err)
+	}
+	return foreignKeys, nil
+}
+
+const GetCheckConstraintsQuery = `
+SELECT
+    c.conname::text,
+    ARRAY(
+        SELECT a.attname::text
+        FROM unnest(c.conkey) AS k(attnum)
+        JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+        ORDER BY a.attnum
+    ),
+    pg_get_expr(c.conbin, c.conrelid)
+FROM pg_constraint c
+JOIN pg_class t ON t.oid = c.conrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE c.contype = 'c' AND t.relname = $1 AND n.nspname = $2
+ORDER BY c.conname
+`
+
+func (db *DB) getCheckConstraints(ctx context.Context, tableName string) ([]domains.CheckConstraint, error) {
+	rows, err := db.Pool.Query(ctx, GetCheckConstraintsQuery, tableName, SchemaFromContext(ctx))
+	if err != nil {
+		logrus.Errorf("failed to get check constraints: %v", err)
+		return nil, fmt.Errorf("failed to get check constraints: %w", err)
+	}
+
+	checks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domains.CheckConstraint, error) {
+		var check domains.CheckConstraint
+		err := row.Scan(&check.Name, &check.Columns, &check.Expression)
+		return check, err
+	})
+	if err != nil {
+		logrus.Errorf("failed to scan check constraints: %v", err)
+		return nil, fmt.Errorf("failed to scan check constraints: %w", err)
+	}
+	return checks, nil
+}
+
+const GetIndexesQuery = `
+SELECT
+    ic.relname::text,
+    ARRAY(
+        SELECT pg_get_indexdef(i.indexrelid, k, true)
+        FROM generate_series(1, i.indnkeyatts) AS k
+        ORDER BY k
+    ),
+    i.indisunique,
+    i.indisprimary,
+    am.amname::text,
+    pg_get_expr(i.indpred, i.indrelid)
+FROM pg_index i
+JOIN pg_class ic ON ic.oid = i.indexrelid
+JOIN pg_am am ON am.oid = ic.relam
+JOIN pg_class t ON t.oid = i.indrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE t.relname = $1 AND n.nspname = $2
+ORDER BY ic.relname
+`
+
+func (db *DB) getIndexes(ctx context.Context, tableName string) ([]domains.Index, error) {
+	rows, err := db.Pool.Query(ctx, GetIndexesQuery, tableName, SchemaFromContext(ctx))
+	if err != nil {
+		logrus.Errorf("failed to get indexes: %v", err)
+		return nil, fmt.Errorf("failed to get indexes: %w", err)
+	}
+
+	indexes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domains.Index, error) {
+		var index domains.Index
+		err := row.Scan(&index.Name, &index.Columns, &index.IsUnique, &index.IsPrimary, &index.Method, &index.Predicate)
+		return index, err
+	})
+	if err != nil {
+		logrus.Errorf("failed to scan indexes: %v", err)
+		return nil, fmt.Errorf("failed to scan indexes: %w", err)
+	}
+	return indexes, nil
+}
@@ -1,16 +1,38 @@
 package db
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
+	"sync"
 	"time"
 )
 
+// DefaultSchema holds the tables unless the request is routed to a tenant
+// schema.
+const DefaultSchema = "public"
+
+// Querier is implemented by both the pool and transactions.
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type DB struct {
 	Pool *pgxpool.Pool
+
+	schemaCacheTTL time.Duration
+	schemaMu       sync.RWMutex
+	schemaCache    map[string]cachedSchema
 }
 
 func NewConnection(cfg *config.GenApiConfig) (*DB, error) {
@@ -29,6 +51,10 @@ func NewConnection(cfg *config.GenApiConfig) (*DB, error) {
 	poolConfig.MinConns = 5
 	poolConfig.MaxConnLifetime = time.Hour
 	poolConfig.MaxConnIdleTime = time.Minute * 30
+	poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		registerJSONTypes(conn.TypeMap())
+		return nil
+	}
 
 	ctx := context.Background()
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
@@ -45,7 +71,19 @@ func NewConnection(cfg *config.GenApiConfig) (*DB, error) {
 
 	logrus.Info("successfully connected to PostgreSQL database with pgxpool")
 
-	return &DB{Pool: pool}, nil
+	return &DB{
+		Pool:           pool,
+		schemaCacheTTL: cfg.GetSchemaCacheTTL(),
+		schemaCache:    make(map[string]cachedSchema),
+	}, nil
+}
+
+// SchemaFromContext returns the schema the request is routed to.
+func SchemaFromContext(ctx context.Context) string {
+	if schema := domains.SchemaFromContext(ctx); schema != "" {
+		return schema
+	}
+	return DefaultSchema
 }
 
 func (db *DB) Close() {
@@ -55,40 +93,15 @@ func (db *DB) Close() {
 	}
 }
 
-const GetTableInfoQuery = `
-SELECT column_name 
-		FROM information_schema.columns 
-		WHERE table_name = $1 
-		AND table_schema = 'public'
-		ORDER BY ordinal_position
-`
-
 func (db *DB) GetTableInfo(ctx context.Context, tableName string) ([]string, error) {
-
-	rows, err := db.Pool.Query(ctx, GetTableInfoQuery, tableName)
+	schema, err := db.GetTableSchema(ctx, tableName)
 	if err != nil {
-		logrus.Errorf("failed to get table info: %v", err)
-		return nil, fmt.Errorf("failed to get table info: %w", err)
-	}
-	defer rows.Close()
-
-	var columns []string
-	for rows.Next() {
-		var columnName string
-		if err := rows.Scan(&columnName); err != nil {
-			logrus.Errorf("failed to scan column name: %v", err)
-			continue
-		}
-		columns = append(columns, columnName)
-	}
-
-	if err = rows.Err(); err != nil {
-		logrus.Errorf("rows iteration error: %v", err)
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+		return nil, err
 	}
 
-	if len(columns) == 0 {
-		return nil, fmt.Errorf("table '%s' not found or has no columns", tableName)
+	columns := make([]string, 0, len(schema.Columns))
+	for _, column := range schema.Columns {
+		columns = append(columns, column.Name)
 	}
 
 	return columns, nil
@@ -97,14 +110,14 @@ func (db *DB) GetTableInfo(ctx context.Context, tableName string) ([]string, err
 const TableExistsQuery = `
 SELECT EXISTS (
 			SELECT 1 FROM information_schema.tables 
-			WHERE table_schema = 'public' 
+			WHERE table_schema = $2
 			AND table_name = $1
 		)
 `
 
 func (db *DB) TableExists(ctx context.Context, tableName string) (bool, error) {
 	var exists bool
-	err := db.Pool.QueryRow(ctx, TableExistsQuery, tableName).Scan(&exists)
+	err := db.Pool.QueryRow(ctx, TableExistsQuery, tableName, SchemaFromContext(ctx)).Scan(&exists)
 	if err != nil {
 		logrus.Errorf("failed to check table existence: %v", err)
 		return false, fmt.Errorf("failed to check table existence: %w", err)
@@ -118,15 +131,16 @@ SELECT kcu.column_name
 		FROM information_schema.table_constraints tc
 		JOIN information_schema.key_column_usage kcu
 			ON tc.constraint_name = kcu.constraint_name
-		WHERE tc.table_name = $1 
+			AND tc.table_schema = kcu.table_schema
+		WHERE tc.table_name = $1
 			AND tc.constraint_type = 'PRIMARY KEY'
-			AND tc.table_schema = 'public'
+			AND tc.table_schema = $2
 		LIMIT 1
 `
 
 func (db *DB) GetPrimaryKeyColumn(ctx context.Context, tableName string) (string, error) {
 	var pkColumn string
-	err := db.Pool.QueryRow(ctx, GetPrimaryKeyColumnQuery, tableName).Scan(&pkColumn)
+	err := db.Pool.QueryRow(ctx, GetPrimaryKeyColumnQuery, tableName, SchemaFromContext(ctx)).Scan(&pkColumn)
 	if err != nil {
 		logrus.Errorf("failed to get primary key column: %v", err)
 		return "", fmt.Errorf("failed to get primary key column: %w", err)
@@ -134,3 +148,82 @@ func (db *DB) GetPrimaryKeyColumn(ctx context.Context, tableName string) (string
 
 	return pkColumn, nil
 }
+
+const ListTablesQuery = `
+SELECT table_name::text
+		FROM information_schema.tables
+		WHERE table_schema = $1
+			AND table_type IN ('BASE TABLE', 'VIEW')
+		ORDER BY table_name
+`
+
+// ListTables returns the tables and views of the schema the request is
+// routed to.
+func (db *DB) ListTables(ctx context.Context) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, ListTablesQuery, SchemaFromContext(ctx))
+	if err != nil {
+		logrus.Errorf("failed to list tables: %v", err)
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		logrus.Errorf("failed to scan table names: %v", err)
+		return nil, fmt.Errorf("failed to scan table names: %w", err)
+	}
+	return tables, nil
+}
+
+const ListTableSummariesQuery = `
+SELECT
+    c.relname::text,
+    CASE WHEN c.relkind = 'v' THEN 'view' ELSE 'table' END,
+    CASE WHEN c.relkind = 'v' OR c.reltuples < 0 THEN NULL ELSE c.reltuples::bigint END,
+    obj_description(c.oid, 'pg_class')
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1
+    AND c.relkind IN ('r', 'p', 'v')
+    AND has_table_privilege(c.oid, 'SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, TRIGGER')
+ORDER BY c.relname
+`
+
+// ListTableSummaries returns the tables and views of the schema the request
+// is routed to, with the row estimates of the planner statistics. Privileges
+// are checked for the current role of q.
+func (db *DB) ListTableSummaries(ctx context.Context, q Querier) ([]domains.TableSummary, error) {
+	rows, err := q.Query(ctx, ListTableSummariesQuery, SchemaFromContext(ctx))
+	if err != nil {
+		logrus.Errorf("failed to list tables: %v", err)
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	tables, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domains.TableSummary, error) {
+		var table domains.TableSummary
+		err := row.Scan(&table.Name, &table.Type, &table.EstimatedRows, &table.Comment)
+		return table, err
+	})
+	if err != nil {
+		logrus.Errorf("failed to scan tables: %v", err)
+		return nil, fmt.Errorf("failed to scan tables: %w", err)
+	}
+	return tables, nil
+}
+
+// registerJSONTypes decodes the numbers of json and jsonb values as
+// json.Number, so that they keep their exact digits instead of going through
+// float64.
+func registerJSONTypes(types *pgtype.Map) {
+	jsonType := &pgtype.Type{Name: "json", OID: pgtype.JSONOID, Codec: &pgtype.JSONCodec{Marshal: json.Marshal, Unmarshal: unmarshalJSONNumbers}}
+	jsonbType := &pgtype.Type{Name: "jsonb", OID: pgtype.JSONBOID, Codec: &pgtype.JSONBCodec{Marshal: json.Marshal, Unmarshal: unmarshalJSONNumbers}}
+	types.RegisterType(jsonType)
+	types.RegisterType(jsonbType)
+	types.RegisterType(&pgtype.Type{Name: "_json", OID: pgtype.JSONArrayOID, Codec: &pgtype.ArrayCodec{ElementType: jsonType}})
+	types.RegisterType(&pgtype.Type{Name: "_jsonb", OID: pgtype.JSONBArrayOID, Codec: &pgtype.ArrayCodec{ElementType: jsonbType}})
+}
+
+func unmarshalJSONNumbers(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type cachedSchema struct {
+	info      *domains.TableInfo
+	expiresAt time.Time
+}
+
+const GetTableSchemaQuery = `
+SELECT
+    c.column_name::text,
+    c.ordinal_position::int,
+    CASE
+        WHEN c.data_type = 'ARRAY' THEN (regexp_replace(c.udt_name, '^_', '') || '[]')
+        WHEN c.data_type = 'USER-DEFINED' THEN c.udt_name
+        ELSE c.data_type
+    END::text AS actual_type,
+    c.is_nullable = 'YES' AS is_nullable,
+    c.column_default::text,
+    c.column_default IS NOT NULL OR c.is_identity = 'YES' OR c.is_generated = 'ALWAYS' AS has_default,
+    c.is_generated = 'ALWAYS' OR COALESCE(c.identity_generation, '') = 'ALWAYS' AS is_generated,
+    c.character_maximum_length::int,
+    c.numeric_precision::int,
+    c.numeric_scale::int,
+    COALESCE(
+        (SELECT array_agg(e.enumlabel::text ORDER BY e.enumsortorder)
+         FROM pg_type t
+         JOIN pg_namespace n ON n.oid = t.typnamespace
+         JOIN pg_enum e ON e.enumtypid = t.oid
+         WHERE t.typname = c.udt_name AND n.nspname = c.udt_schema),
+        '{}'
+    ) AS enum_values,
+    col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position::int)
+FROM information_schema.columns c
+WHERE c.table_name = $1 AND c.table_schema = $2
+ORDER BY c.ordinal_position
+`
+
+// GetTableSchema returns the column metadata of a table in the schema the
+// request is routed to. Results are cached per schema for the configured
+// schema cache TTL.
+func (db *DB) GetTableSchema(ctx context.Context, tableName string) (*domains.TableInfo, error) {
+	cacheKey := SchemaFromContext(ctx) + "." + tableName
+
+	db.schemaMu.RLock()
+	cached, ok := db.schemaCache[cacheKey]
+	db.schemaMu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.info, nil
+	}
+
+	info, err := db.loadTableSchema(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	db.schemaMu.Lock()
+	db.schemaCache[cacheKey] = cachedSchema{info: info, expiresAt: time.Now().Add(db.schemaCacheTTL)}
+	db.schemaMu.Unlock()
+
+	return info, nil
+}
+
+// InvalidateSchemaCache drops cached metadata so that the next request reads it again.
+func (db *DB) InvalidateSchemaCache() {
+	db.schemaMu.Lock()
+	db.schemaCache = make(map[string]cachedSchema)
+	db.schemaMu.Unlock()
+}
+
+const GetTableCommentQuery = `
+SELECT obj_description(c.oid, 'pg_class')
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relname = $1 AND n.nspname = $2
+`
+
+func (db *DB) getTableComment(ctx context.Context, tableName string) (*string, error) {
+	var comment *string
+	err := db.Pool.QueryRow(ctx, GetTableCommentQuery, tableName, SchemaFromContext(ctx)).Scan(&comment)
+	if err != nil {
+		logrus.Errorf("failed to get table comment: %v", err)
+		return nil, fmt.Errorf("failed to get table comment: %w", err)
+	}
+	return comment, nil
+}
+
+func (db *DB) loadTableSchema(ctx context.Context, tableName string) (*domains.TableInfo, error) {
+	rows, err := db.Pool.Query(ctx, GetTableSchemaQuery, tableName, SchemaFromContext(ctx))
+	if err != nil {
+		logrus.Errorf("failed to get table schema: %v", err)
+		return nil, fmt.Errorf("failed to get table schema: %w", err)
+	}
+	defer rows.Close()
+
+	info := &domains.TableInfo{Name: tableName}
+	for rows.Next() {
+		var column domains.DatabaseColumn
+		if err := rows.Scan(
+			&column.Name,
+			&column.Position,
+			&column.DataType,
+			&column.IsNullable,
+			&column.DefaultValue,
+			&column.HasDefault,
+			&column.IsGenerated,
+			&column.MaxLength,
+			&column.NumericPrecision,
+			&column.NumericScale,
+			&column.EnumValues,
+			&column.Comment,
+		); err != nil {
+			logrus.Errorf("failed to scan column metadata: %v", err)
+			return nil, fmt.Errorf("failed to scan column metadata: %w", err)
+		}
+		info.Columns = append(info.Columns, column)
+	}
+
+	if err = rows.Err(); err != nil {
+		logrus.Errorf("rows iteration error: %v", err)
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	if len(info.Columns) == 0 {
+		return nil, domains.NewError(domains.ErrCodeNotFound, fmt.Sprintf("table '%s' not found or has no columns", tableName))
+	}
+
+	pkColumn, err := db.GetPrimaryKeyColumn(ctx, tableName)
+	if err != nil {
+		logrus.Warnf("could not get primary key for table %s: %v", tableName, err)
+	}
+	info.PrimaryKey = pkColumn
+
+	if info.ForeignKeys, err = db.getForeignKeys(ctx, tableName); err != nil {
+		return nil, err
+	}
+	if info.CheckConstraints, err = db.getCheckConstraints(ctx, tableName); err != nil {
+		return nil, err
+	}
+	if info.Indexes, err = db.getIndexes(ctx, tableName); err != nil {
+		return nil, err
+	}
+	if info.Comment, err = db.getTableComment(ctx, tableName); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"regexp"
+)
+
+const (
+	pgUniqueViolation           = "23505"
+	pgForeignKeyViolation       = "23503"
+	pgNotNullViolation          = "23502"
+	pgCheckViolation            = "23514"
+	pgInvalidTextRepresentation = "22P02"
+	pgInsufficientPrivilege     = "42501"
+	pgQueryCanceled             = "57014"
+)
+
+const (
+	opCreate = "create item"
+	opGet    = "get item"
+	opList   = "query items"
+	opCount  = "count items"
+	opUpdate = "update item"
+	opDelete = "delete item"
+)
+
+var detailKeyPattern = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+var errItemNotFound = domains.NewError(domains.ErrCodeNotFound, "item not found")
+
+// translateError converts driver errors into domains.Error values. The
+// operation decides how foreign key violations are classified: deleting a row
+// that is still referenced is a conflict, writing a dangling reference is a
+// validation problem.
+func translateError(err error, operation string) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errItemNotFound
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &domains.Error{Code: domains.ErrCodeQueryTimeout, Message: fmt.Sprintf("failed to %s", operation), Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return fmt.Errorf("failed to %s: %w", operation, err)
+	}
+
+	appErr := &domains.Error{
+		Message:    fmt.Sprintf("failed to %s", operation),
+		Table:      pgErr.TableName,
+		Constraint: pgErr.ConstraintName,
+		Column:     pgErr.ColumnName,
+		Err:        err,
+	}
+	if appErr.Column == "" {
+		if match := detailKeyPattern.FindStringSubmatch(pgErr.Detail); match != nil {
+			appErr.Column = match[1]
+		}
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		appErr.Code = domains.ErrCodeUniqueViolation
+	case pgForeignKeyViolation:
+		if operation == opDelete {
+			appErr.Code = domains.ErrCodeRowReferenced
+		} else {
+			appErr.Code = domains.ErrCodeForeignKeyViolation
+		}
+	case pgNotNullViolation:
+		appErr.Code = domains.ErrCodeNotNullViolation
+	case pgCheckViolation:
+		appErr.Code = domains.ErrCodeCheckViolation
+	case pgInvalidTextRepresentation:
+		appErr.Code = domains.ErrCodeInvalidInput
+	case pgInsufficientPrivilege:
+		appErr.Code = domains.ErrCodePermissionDenied
+	case pgQueryCanceled:
+		appErr.Code = domains.ErrCodeQueryTimeout
+	default:
+		appErr.Code = domains.ErrCodeInternal
+	}
+
+	return appErr
+}
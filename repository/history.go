@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/repository/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+// createHistoryFunctionQuery installs the trigger function shared by all
+// versioned tables. It closes the current version of the old row and opens a
+// new one for the new row. The function runs as its owner so that clients do
+// not need write access to the history tables. Its search path is pinned so
+// that callers cannot shadow the functions it uses. The history table is named
+// after the table the trigger fires on, never taken from a trigger argument,
+// so that triggers created by other roles cannot make it write elsewhere; the
+// suffix matches config.HistoryTableSuffix. Only its owner may attach it to
+// tables.
+const createHistoryFunctionQuery = `
+CREATE OR REPLACE FUNCTION genapi_record_history() RETURNS trigger
+LANGUAGE plpgsql SECURITY DEFINER SET search_path = pg_catalog, pg_temp AS $$
+DECLARE
+    history_table text := TG_TABLE_NAME || '_history';
+    pk_column text := TG_ARGV[0];
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        EXECUTE format('UPDATE %I.%I SET valid_to = now() WHERE row_pk = $1 AND valid_to IS NULL', TG_TABLE_SCHEMA, history_table)
+            USING to_jsonb(OLD) ->> pk_column;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        EXECUTE format('INSERT INTO %I.%I (row_pk, operation, row_data, valid_from) VALUES ($1, $2, $3, now())', TG_TABLE_SCHEMA, history_table)
+            USING to_jsonb(NEW) ->> pk_column, lower(TG_OP), to_jsonb(NEW);
+    END IF;
+    RETURN NULL;
+END
+$$;
+REVOKE EXECUTE ON FUNCTION genapi_record_history() FROM PUBLIC
+`
+
+// createHistoryTableQuery creates the shadow table of a versioned table,
+// (re)installs its trigger and snapshots the rows that have no open version
+// yet, typically the ones written before versioning was enabled.
+const createHistoryTableQuery = `
+CREATE TABLE IF NOT EXISTS %[1]s (
+    version_id BIGSERIAL PRIMARY KEY,
+    row_pk TEXT NOT NULL,
+    operation TEXT NOT NULL,
+    row_data JSONB NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (row_pk, valid_from);
+DROP TRIGGER IF EXISTS genapi_history ON %[3]s;
+CREATE TRIGGER genapi_history AFTER INSERT OR UPDATE OR DELETE ON %[3]s
+    FOR EACH ROW EXECUTE FUNCTION genapi_record_history(%[4]s);
+INSERT INTO %[1]s (row_pk, operation, row_data, valid_from)
+SELECT to_jsonb(t) ->> %[4]s, 'snapshot', to_jsonb(t), now() FROM %[3]s AS t
+WHERE NOT EXISTS (
+    SELECT 1 FROM %[1]s AS h WHERE h.row_pk = to_jsonb(t) ->> %[4]s AND h.valid_to IS NULL
+)
+`
+
+// enableHistoryRowSecurityQuery enables row-level security on a history
+// table. Without policies of its own it hides every version from the request
+// roles, the policies of the live table do not apply to it.
+const enableHistoryRowSecurityQuery = `ALTER TABLE %s ENABLE ROW LEVEL SECURITY`
+
+// EnsureHistoryTables installs versioning on every table listed in the
+// history config.
+func (r *ItemRepository) EnsureHistoryTables(ctx context.Context) error {
+	if _, err := r.db.Pool.Exec(ctx, createHistoryFunctionQuery); err != nil {
+		logrus.Errorf("failed to create history trigger function: %v", err)
+		return fmt.Errorf("failed to create history trigger function: %w", err)
+	}
+
+	var schema string
+	if err := r.db.Pool.QueryRow(ctx, "SELECT current_schema()").Scan(&schema); err != nil {
+		logrus.Errorf("failed to get current schema: %v", err)
+		return fmt.Errorf("failed to get current schema: %w", err)
+	}
+
+	for _, tableName := range r.cfg.History.Tables {
+		pkColumn, err := r.db.GetPrimaryKeyColumn(ctx, tableName)
+		if err != nil {
+			return fmt.Errorf("failed to get primary key of versioned table %s: %w", tableName, err)
+		}
+
+		historyTable := config.HistoryTable(tableName)
+		qualifiedHistoryTable := pgx.Identifier{schema, historyTable}.Sanitize()
+		query := fmt.Sprintf(createHistoryTableQuery,
+			qualifiedHistoryTable,
+			r.quoteIdentifier(historyTable+"_pk_idx"),
+			pgx.Identifier{schema, tableName}.Sanitize(),
+			quoteLiteral(pkColumn),
+		)
+		if r.cfg.RowLevelSecurity != nil {
+			query += ";\n" + fmt.Sprintf(enableHistoryRowSecurityQuery, qualifiedHistoryTable)
+		}
+		if _, err := r.db.Pool.Exec(ctx, query); err != nil {
+			logrus.Errorf("failed to create history table for %s: %v", tableName, err)
+			return fmt.Errorf("failed to create history table for %s: %w", tableName, err)
+		}
+	}
+
+	return nil
+}
+
+const getRowHistoryQuery = `
+SELECT version_id, operation, valid_from, valid_to, row_data - $2::text[]
+FROM %s
+WHERE row_pk = $1%s
+ORDER BY valid_from, version_id`
+
+// GetHistory lists every version of a row, oldest first.
+func (r *ItemRepository) GetHistory(ctx context.Context, tableName string, id any) ([]domains.RowVersion, error) {
+	scope, err := r.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{fmt.Sprint(id), r.cfg.HiddenColumns(tableName)}
+	var tenantCondition string
+	if scope != nil {
+		tenantCondition = " AND row_data ->> $3 = $4"
+		args = append(args, scope.Column, scope.Value)
+	}
+	query := fmt.Sprintf(getRowHistoryQuery, r.quoteIdentifier(config.HistoryTable(tableName)), tenantCondition)
+
+	versions := []domains.RowVersion{}
+	err = r.withSession(ctx, func(q db.Querier) error {
+		rows, err := q.Query(ctx, query, args...)
+		if err != nil {
+			logrus.Errorf("failed to get history from table %s: %v", tableName, err)
+			return translateError(err, opGet)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var version domains.RowVersion
+			if err := rows.Scan(&version.VersionID, &version.Operation, &version.ValidFrom, &version.ValidTo, &version.Data); err != nil {
+				logrus.Errorf("failed to scan history of table %s: %v", tableName, err)
+				return translateError(err, opGet)
+			}
+			versions = append(versions, version)
+		}
+
+		if err = rows.Err(); err != nil {
+			logrus.Errorf("rows iteration error for history of table %s: %v", tableName, err)
+			return translateError(err, opGet)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, errItemNotFound
+	}
+	return versions, nil
+}
+
+// readSource returns the relation that reads select from: the table itself,
+// or its state at asOf rebuilt from the history table. The rebuilt relation
+// has the row type of the live table and is aliased to its name, so the rest
+// of the query does not change.
+func (r *ItemRepository) readSource(tableName string, asOf *time.Time, argIndex int) (string, []any) {
+	table := r.quoteIdentifier(tableName)
+	if asOf == nil {
+		return table, nil
+	}
+
+	source := fmt.Sprintf(
+		"(SELECT v.* FROM %s AS h CROSS JOIN LATERAL jsonb_populate_record(NULL::%s, h.row_data) AS v "+
+			"WHERE h.valid_from <= $%d AND (h.valid_to IS NULL OR h.valid_to > $%d)) AS %s",
+		r.quoteIdentifier(config.HistoryTable(tableName)), table, argIndex, argIndex, table,
+	)
+	return source, []any{*asOf}
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
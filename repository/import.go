@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sirupsen/logrus"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const opImport = "import items"
+
+// importStagingTable receives the rows of an import before they are inserted
+// when COPY cannot write to the table directly. Tables with row-level security
+// reject COPY FROM, and audited imports write their entries from the inserted
+// rows.
+const importStagingTable = "genapi_import"
+
+var copyLinePattern = regexp.MustCompile(`COPY \S+, line (\d+)`)
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+// importTimeLayouts are accepted for date and timestamp columns besides the
+// PostgreSQL text format.
+var importTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+}
+
+// Import copies rows into the table with COPY in a single transaction. next
+// returns the values of the next row in the order of columns, io.EOF after
+// the last row, or an error that aborts the import. The transaction is rolled
+// back on errors and when dryRun is set. It returns the number of rows copied.
+func (r *ItemRepository) Import(ctx context.Context, tableName string, columns []string, next func() ([]any, error), dryRun bool) (int64, error) {
+	scope, err := r.TenantScope(ctx, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if scope != nil {
+		columns = append(columns[:len(columns):len(columns)], scope.Column)
+	}
+
+	var imported int64
+	err = r.inTransaction(ctx, func(tx pgx.Tx) error {
+		oids, err := r.columnOIDs(ctx, tx, tableName, columns)
+		if err != nil {
+			return err
+		}
+
+		source := &copySource{
+			next: next,
+			convert: func(row int, values []any) ([]any, error) {
+				if scope != nil {
+					values = append(values, scope.Value)
+				}
+				for i, value := range values {
+					converted, err := copyValue(tx.Conn().TypeMap(), oids[i], value)
+					if err != nil {
+						return nil, &domains.ValidationError{Fields: []domains.FieldError{
+							{Field: domains.ImportField(row, columns[i]), Message: err.Error()},
+						}}
+					}
+					values[i] = converted
+				}
+				return values, nil
+			},
+		}
+
+		if r.cfg.Audit == nil && r.cfg.RowLevelSecurity == nil {
+			imported, err = tx.CopyFrom(ctx, pgx.Identifier{tableName}, columns, source)
+		} else {
+			imported, err = r.copyStaged(ctx, tx, tableName, columns, source)
+		}
+		if err != nil {
+			return translateCopyError(err)
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+// copyStaged copies the rows into a staging table and moves them to the table
+// with a single INSERT, which is subject to row-level security policies and
+// also writes the audit entries of the rows when auditing is enabled.
+func (r *ItemRepository) copyStaged(ctx context.Context, tx pgx.Tx, tableName string, columns []string, source pgx.CopyFromSource) (int64, error) {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = r.quoteIdentifier(column)
+	}
+	columnList := strings.Join(quoted, ", ")
+
+	staging := r.quoteIdentifier(importStagingTable)
+	if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA",
+		staging, columnList, r.quoteIdentifier(tableName))); err != nil {
+		logrus.Errorf("failed to create import staging table for %s: %v", tableName, err)
+		return 0, err
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{importStagingTable}, columns, source); err != nil {
+		return 0, err
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", r.quoteIdentifier(tableName), columnList, columnList, staging)
+	if r.cfg.Audit == nil {
+		tag, err := tx.Exec(ctx, insert)
+		if err != nil {
+			return 0, err
+		}
+		return tag.RowsAffected(), nil
+	}
+
+	pkColumn, err := r.db.GetPrimaryKeyColumn(ctx, tableName)
+	if err != nil {
+		logrus.Warnf("could not get primary key for table %s: %v", tableName, err)
+		pkColumn = "id"
+	}
+
+	auditQuery, auditArgs := r.auditInsert(ctx, tableName, pkColumn, domains.AuditOperationCreate, 1)
+	query := fmt.Sprintf("WITH %s AS (%s RETURNING *), audit AS (%s) SELECT COUNT(*) FROM %s",
+		writtenAlias, insert, auditQuery, writtenAlias)
+
+	var imported int64
+	if err := tx.QueryRow(ctx, query, auditArgs...).Scan(&imported); err != nil {
+		return 0, err
+	}
+	return imported, nil
+}
+
+// columnOIDs returns the type of every column as reported by the server, which
+// decides how the values are encoded by COPY.
+func (r *ItemRepository) columnOIDs(ctx context.Context, tx pgx.Tx, tableName string, columns []string) ([]uint32, error) {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = r.quoteIdentifier(column)
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT %s FROM %s LIMIT 0", strings.Join(quoted, ", "), r.quoteIdentifier(tableName)))
+	if err != nil {
+		logrus.Errorf("failed to read column types of table %s: %v", tableName, err)
+		return nil, translateError(err, opImport)
+	}
+	defer rows.Close()
+
+	oids := make([]uint32, len(columns))
+	for i, field := range rows.FieldDescriptions() {
+		oids[i] = field.DataTypeOID
+	}
+	return oids, nil
+}
+
+// translateCopyError passes validation errors through and reports the row a
+// database error was raised for when the server tells it.
+func translateCopyError(err error) error {
+	var validationErr *domains.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr
+	}
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	logrus.Errorf("failed to import items: %v", err)
+	translated := translateError(err, opImport)
+
+	var pgErr *pgconn.PgError
+	var appErr *domains.Error
+	if errors.As(err, &pgErr) && errors.As(translated, &appErr) {
+		if match := copyLinePattern.FindStringSubmatch(pgErr.Where); match != nil {
+			appErr.Message = fmt.Sprintf("failed to import row %s", match[1])
+		}
+	}
+	return translated
+}
+
+// copySource feeds the rows returned by next to CopyFrom.
+type copySource struct {
+	next    func() ([]any, error)
+	convert func(row int, values []any) ([]any, error)
+	row     int
+	values  []any
+	err     error
+}
+
+func (s *copySource) Next() bool {
+	values, err := s.next()
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		return false
+	}
+
+	s.row++
+	s.values, s.err = s.convert(s.row, values)
+	return s.err == nil
+}
+
+func (s *copySource) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *copySource) Err() error {
+	return s.err
+}
+
+// copyValue converts a validated value to a type that pgx encodes in the
+// binary format of COPY. Strings are parsed with the text format of the
+// column type, JSON columns take any JSON value.
+func copyValue(types *pgtype.Map, oid uint32, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch oid {
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be valid JSON")
+		}
+		return encoded, nil
+	}
+
+	dataType, known := types.TypeForOID(oid)
+
+	switch v := value.(type) {
+	case []any:
+		arrayCodec, ok := dataType.Codec.(*pgtype.ArrayCodec)
+		if !known || !ok {
+			return nil, fmt.Errorf("must not be an array")
+		}
+		dims, elements, err := flattenArray(v, arrayCodec.ElementType.Name+"[]")
+		if err != nil {
+			return nil, err
+		}
+		for i, element := range elements {
+			converted, err := copyValue(types, arrayCodec.ElementType.OID, element)
+			if err != nil {
+				return nil, fmt.Errorf("element %d %w", i, err)
+			}
+			elements[i] = converted
+		}
+		return pgtype.Array[any]{Elements: elements, Dims: dims, Valid: true}, nil
+	case json.Number:
+		value = v.String()
+	case bool:
+		value = strconv.FormatBool(v)
+	case float64:
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	if !known {
+		// Enums and other types without a codec take their text as is.
+		return text, nil
+	}
+
+	switch oid {
+	case pgtype.DateOID, pgtype.TimestampOID, pgtype.TimestamptzOID:
+		for _, layout := range importTimeLayouts {
+			if parsed, err := time.Parse(layout, text); err == nil {
+				return parsed, nil
+			}
+		}
+	}
+
+	var decoded any
+	if err := types.Scan(oid, pgtype.TextFormatCode, []byte(text), &decoded); err != nil {
+		return nil, fmt.Errorf("is not a valid %s", dataType.Name)
+	}
+	return decoded, nil
+}
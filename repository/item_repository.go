@@ -4,25 +4,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
 	"github.com/abdulaziz-go/go-gen-apis/domains"
 	"github.com/abdulaziz-go/go-gen-apis/repository/db"
-	"github.com/abdulaziz-go/go-gen-apis/utils"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/sirupsen/logrus"
 	"strings"
+	"time"
 )
 
 type ItemRepository struct {
-	db *db.DB
+	db  *db.DB
+	cfg *config.GenApiConfig
 }
 
-func NewItemRepository(db *db.DB) *ItemRepository {
-	return &ItemRepository{db: db}
+func NewItemRepository(db *db.DB, cfg *config.GenApiConfig) *ItemRepository {
+	return &ItemRepository{db: db, cfg: cfg}
 }
 
 func (r *ItemRepository) Create(ctx context.Context, tableName string, dataArray []map[string]any) ([]map[string]any, error) {
 	if len(dataArray) == 0 {
-		return nil, fmt.Errorf("no data provided for creation")
+		return nil, domains.NewError(domains.ErrCodeValidationFailed, "no data provided for creation")
 	}
 
 	columns, err := r.db.GetTableInfo(ctx, tableName)
@@ -36,52 +39,93 @@ func (r *ItemRepository) Create(ctx context.Context, tableName string, dataArray
 		pkColumn = "id"
 	}
 
-	var results []map[string]any
+	readableColumns, err := r.readableColumns(tableName, columns)
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := r.getColumnTypes(ctx, tableName)
+
+	scope, err := r.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, data := range dataArray {
-		var insertColumns []string
-		var placeholders []string
-		var values []any
-		paramIndex := 1
+	var results []map[string]any
 
-		for _, col := range columns {
-			if col == pkColumn {
-				continue
+	err = r.withSession(ctx, func(q db.Querier) error {
+		for _, data := range dataArray {
+			if scope != nil {
+				data = withTenant(data, scope)
 			}
-			if value, exists := data[col]; exists {
-				insertColumns = append(insertColumns, r.quoteIdentifier(col))
-				placeholders = append(placeholders, fmt.Sprintf("$%d", paramIndex))
-				values = append(values, value)
-				paramIndex++
+
+			var insertColumns []string
+			var placeholders []string
+			var values []any
+			paramIndex := 1
+
+			for _, col := range columns {
+				if col == pkColumn {
+					continue
+				}
+				if value, exists := data[col]; exists {
+					param, err := columnParam(col, value, columnTypes)
+					if err != nil {
+						return err
+					}
+					insertColumns = append(insertColumns, r.quoteIdentifier(col))
+					placeholders = append(placeholders, fmt.Sprintf("$%d", paramIndex))
+					values = append(values, param)
+					paramIndex++
+				}
 			}
-		}
 
-		if len(insertColumns) == 0 {
-			logrus.Warnf("no valid columns found for insert in one of the data items")
-			continue
-		}
+			if len(insertColumns) == 0 {
+				logrus.Warnf("no valid columns found for insert in one of the data items")
+				continue
+			}
 
-		query := fmt.Sprintf(
-			"INSERT INTO %s (%s) VALUES (%s) RETURNING *",
-			r.quoteIdentifier(tableName),
-			strings.Join(insertColumns, ", "),
-			strings.Join(placeholders, ", "),
-		)
+			query := fmt.Sprintf(
+				"INSERT INTO %s (%s) VALUES (%s) RETURNING %s",
+				r.quoteIdentifier(tableName),
+				strings.Join(insertColumns, ", "),
+				strings.Join(placeholders, ", "),
+				r.selectList(readableColumns, columnTypes),
+			)
+
+			if r.cfg.Audit != nil {
+				auditQuery, auditArgs := r.auditInsert(ctx, tableName, pkColumn, domains.AuditOperationCreate, paramIndex)
+				query = fmt.Sprintf(
+					"WITH %s AS (INSERT INTO %s (%s) VALUES (%s) RETURNING *), audit AS (%s) SELECT %s FROM %s",
+					writtenAlias,
+					r.quoteIdentifier(tableName),
+					strings.Join(insertColumns, ", "),
+					strings.Join(placeholders, ", "),
+					auditQuery,
+					r.selectList(readableColumns, columnTypes),
+					writtenAlias,
+				)
+				values = append(values, auditArgs...)
+			}
 
-		row := r.db.Pool.QueryRow(ctx, query, values...)
+			row := q.QueryRow(ctx, query, values...)
 
-		result, err := r.parseRowToMap(row, columns, tableName)
-		if err != nil {
-			logrus.Errorf("failed to create item in table %s: %v", tableName, err)
-			return nil, fmt.Errorf("failed to create item: %w", err)
+			result, err := r.parseRowToMap(row, readableColumns, columnTypes)
+			if err != nil {
+				logrus.Errorf("failed to create item in table %s: %v", tableName, err)
+				return translateError(err, opCreate)
+			}
+			results = append(results, result)
 		}
-		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return results, nil
 }
 
-func (r *ItemRepository) GetByID(ctx context.Context, tableName string, id any) (map[string]any, error) {
+func (r *ItemRepository) GetByID(ctx context.Context, tableName string, id any, asOf *time.Time) (map[string]any, error) {
 	columns, err := r.db.GetTableInfo(ctx, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table info: %w", err)
@@ -93,41 +137,85 @@ func (r *ItemRepository) GetByID(ctx context.Context, tableName string, id any)
 		pkColumn = "id"
 	}
 
-	var selectColumns []string
-	for _, col := range columns {
-		selectColumns = append(selectColumns, fmt.Sprintf("%s::text as %s", r.quoteIdentifier(col), r.quoteIdentifier(col)))
+	readableColumns, err := r.readableColumns(tableName, columns)
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := r.getColumnTypes(ctx, tableName)
+
+	scope, err := r.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
 	}
 
-	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1",
-		strings.Join(selectColumns, ", "), r.quoteIdentifier(tableName), r.quoteIdentifier(pkColumn))
+	tenantCondition, args := r.tenantCondition(scope, "", 2)
+	source, sourceArgs := r.readSource(tableName, asOf, len(args)+2)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1%s",
+		r.selectList(readableColumns, columnTypes), source, r.quoteIdentifier(pkColumn), tenantCondition)
+	args = append(append([]any{id}, args...), sourceArgs...)
 
-	row := r.db.Pool.QueryRow(ctx, query, id)
+	var result map[string]any
+	err = r.withSession(ctx, func(q db.Querier) error {
+		row := q.QueryRow(ctx, query, args...)
 
-	result, err := r.parseRowToMap(row, columns, tableName)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("item not found")
+		result, err = r.parseRowToMap(row, readableColumns, columnTypes)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errItemNotFound
+			}
+			logrus.Errorf("failed to get item by ID from table %s: %v", tableName, err)
+			return translateError(err, opGet)
 		}
-		logrus.Errorf("failed to get item by ID from table %s: %v", tableName, err)
-		return nil, fmt.Errorf("failed to get item: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-func (r *ItemRepository) GetAll(ctx context.Context, tableName string, filter *domains.ItemFilter) ([]map[string]any, int, error) {
+// listQuery holds the statements of a list request.
+type listQuery struct {
+	columns     []string
+	columnTypes map[string]string
+	count       string
+	countArgs   []any
+	sel         string
+	args        []any
+}
+
+func (r *ItemRepository) buildListQuery(ctx context.Context, tableName string, filter *domains.ItemFilter) (*listQuery, error) {
 	columns, err := r.db.GetTableInfo(ctx, tableName)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get table info: %w", err)
+		return nil, fmt.Errorf("failed to get table info: %w", err)
+	}
+	columns, err = r.readableColumns(tableName, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := r.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
 	}
 
-	baseQuery := fmt.Sprintf("FROM %s", r.quoteIdentifier(tableName))
+	source, args := r.readSource(tableName, filter.AsOf, 1)
+	baseQuery := fmt.Sprintf("FROM %s", source)
 	var whereConditions []string
-	var args []any
-	argIndex := 1
+	argIndex := len(args) + 1
+
+	if scope != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("%s = $%d", r.quoteIdentifier(scope.Column), argIndex))
+		args = append(args, scope.Value)
+		argIndex++
+	}
 
 	if filter.Filters != nil && len(filter.Filters) > 0 {
 		for column, value := range filter.Filters {
+			if scope != nil && column == scope.Column {
+				continue
+			}
 			if r.columnExists(columns, column) {
 				switch v := value.(type) {
 				case []any:
@@ -163,20 +251,14 @@ func (r *ItemRepository) GetAll(ctx context.Context, tableName string, filter *d
 		baseQuery += " WHERE " + strings.Join(whereConditions, " AND ")
 	}
 
-	countQuery := "SELECT COUNT(*) " + baseQuery
-	var total int
-	err = r.db.Pool.QueryRow(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		logrus.Errorf("failed to count items in table %s: %v", tableName, err)
-		return nil, 0, fmt.Errorf("failed to count items: %w", err)
-	}
-
-	var selectColumns []string
-	for _, col := range columns {
-		selectColumns = append(selectColumns, fmt.Sprintf("%s::text as %s", r.quoteIdentifier(col), r.quoteIdentifier(col)))
+	query := &listQuery{
+		columns:     columns,
+		columnTypes: r.getColumnTypes(ctx, tableName),
+		count:       "SELECT COUNT(*) " + baseQuery,
+		countArgs:   args,
 	}
 
-	selectQuery := fmt.Sprintf("SELECT %s %s", strings.Join(selectColumns, ", "), baseQuery)
+	selectQuery := fmt.Sprintf("SELECT %s %s", r.selectList(columns, query.columnTypes), baseQuery)
 
 	if filter.OrderBy != "" && r.columnExists(columns, filter.OrderBy) {
 		sort := domains.SORT_ASC
@@ -198,31 +280,114 @@ func (r *ItemRepository) GetAll(ctx context.Context, tableName string, filter *d
 		args = append(args, filter.Offset)
 	}
 
-	rows, err := r.db.Pool.Query(ctx, selectQuery, args...)
+	query.sel = selectQuery
+	query.args = args
+	return query, nil
+}
+
+func (r *ItemRepository) GetAll(ctx context.Context, tableName string, filter *domains.ItemFilter) ([]map[string]any, int, error) {
+	query, err := r.buildListQuery(ctx, tableName, filter)
 	if err != nil {
-		logrus.Errorf("failed to query items from table %s: %v", tableName, err)
-		return nil, 0, fmt.Errorf("failed to query items: %w", err)
+		return nil, 0, err
 	}
-	defer rows.Close()
+	columns := query.columns
 
+	var total int
 	var items []map[string]any
-	for rows.Next() {
-		item, err := r.parseRowsToMap(rows, columns, tableName)
+	err = r.withSession(ctx, func(q db.Querier) error {
+		if err := q.QueryRow(ctx, query.count, query.countArgs...).Scan(&total); err != nil {
+			logrus.Errorf("failed to count items in table %s: %v", tableName, err)
+			return translateError(err, opCount)
+		}
+
+		rows, err := q.Query(ctx, query.sel, query.args...)
 		if err != nil {
-			logrus.Errorf("failed to scan item from table %s: %v", tableName, err)
-			continue
+			logrus.Errorf("failed to query items from table %s: %v", tableName, err)
+			return translateError(err, opList)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			item, err := r.parseRowsToMap(rows, columns, query.columnTypes)
+			if err != nil {
+				logrus.Errorf("failed to scan item from table %s: %v", tableName, err)
+				continue
+			}
+			items = append(items, item)
 		}
-		items = append(items, item)
-	}
 
-	if err = rows.Err(); err != nil {
-		logrus.Errorf("rows iteration error for table %s: %v", tableName, err)
-		return nil, 0, fmt.Errorf("rows iteration error: %w", err)
+		if err = rows.Err(); err != nil {
+			logrus.Errorf("rows iteration error for table %s: %v", tableName, err)
+			return translateError(err, opList)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
 	}
 
 	return items, total, nil
 }
 
+// Stream writes the rows matching filter to w as they are read, without
+// counting them or holding them in memory.
+func (r *ItemRepository) Stream(ctx context.Context, tableName string, filter *domains.ItemFilter, w domains.RowWriter) error {
+	query, err := r.buildListQuery(ctx, tableName, filter)
+	if err != nil {
+		return err
+	}
+
+	schema, err := r.db.GetTableSchema(ctx, tableName)
+	if err != nil {
+		return fmt.Errorf("failed to get table schema: %w", err)
+	}
+	columns := make([]domains.DatabaseColumn, 0, len(query.columns))
+	for _, name := range query.columns {
+		column := domains.DatabaseColumn{Name: name}
+		for _, candidate := range schema.Columns {
+			if candidate.Name == name {
+				column = candidate
+				break
+			}
+		}
+		columns = append(columns, column)
+	}
+
+	return r.withSession(ctx, func(q db.Querier) error {
+		rows, err := q.Query(ctx, query.sel, query.args...)
+		if err != nil {
+			logrus.Errorf("failed to query items from table %s: %v", tableName, err)
+			return translateError(err, opList)
+		}
+		defer rows.Close()
+
+		if err := w.WriteColumns(columns); err != nil {
+			return err
+		}
+		for rows.Next() {
+			// Stop early when the client went away.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			item, err := r.parseRowsToMap(rows, query.columns, query.columnTypes)
+			if err != nil {
+				// Skipping the row would send an export that looks complete.
+				logrus.Errorf("failed to scan item from table %s: %v", tableName, err)
+				return translateError(err, opList)
+			}
+			if err := w.WriteRow(item); err != nil {
+				return err
+			}
+		}
+
+		if err = rows.Err(); err != nil {
+			logrus.Errorf("rows iteration error for table %s: %v", tableName, err)
+			return translateError(err, opList)
+		}
+		return nil
+	})
+}
+
 func (r *ItemRepository) Update(ctx context.Context, tableName string, id any, data map[string]any) (map[string]any, error) {
 	columns, err := r.db.GetTableInfo(ctx, tableName)
 	if err != nil {
@@ -235,44 +400,92 @@ func (r *ItemRepository) Update(ctx context.Context, tableName string, id any, d
 		pkColumn = "id"
 	}
 
+	scope, err := r.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	columnTypes := r.getColumnTypes(ctx, tableName)
+
 	var updateColumns []string
 	var values []any
 	paramIndex := 1
 
 	for _, col := range columns {
-		if col == pkColumn {
+		if col == pkColumn || (scope != nil && col == scope.Column) {
 			continue
 		}
 		if value, exists := data[col]; exists {
+			param, err := columnParam(col, value, columnTypes)
+			if err != nil {
+				return nil, err
+			}
 			updateColumns = append(updateColumns, fmt.Sprintf("%s = $%d", r.quoteIdentifier(col), paramIndex))
-			values = append(values, value)
+			values = append(values, param)
 			paramIndex++
 		}
 	}
 
 	if len(updateColumns) == 0 {
-		return nil, fmt.Errorf("no valid columns found for update")
+		return nil, domains.NewError(domains.ErrCodeValidationFailed, "no valid columns found for update")
 	}
 
 	values = append(values, id)
 
+	readableColumns, err := r.readableColumns(tableName, columns)
+	if err != nil {
+		return nil, err
+	}
+	table := r.quoteIdentifier(tableName)
+
+	tenantCondition, tenantArgs := r.tenantCondition(scope, table, paramIndex+1)
+	values = append(values, tenantArgs...)
+
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s WHERE %s = $%d RETURNING *",
-		r.quoteIdentifier(tableName),
+		"UPDATE %s SET %s WHERE %s = $%d%s RETURNING %s",
+		table,
 		strings.Join(updateColumns, ", "),
 		r.quoteIdentifier(pkColumn),
 		paramIndex,
+		tenantCondition,
+		r.selectList(readableColumns, columnTypes),
 	)
 
-	row := r.db.Pool.QueryRow(ctx, query, values...)
+	if r.cfg.Audit != nil {
+		auditQuery, auditArgs := r.auditInsert(ctx, tableName, pkColumn, domains.AuditOperationUpdate, len(values)+1)
+		query = fmt.Sprintf(
+			"WITH %[1]s AS (SELECT to_jsonb(%[3]s) AS row_data FROM %[3]s WHERE %[3]s.%[5]s = $%[6]d%[7]s FOR UPDATE), "+
+				"%[2]s AS (UPDATE %[3]s SET %[4]s FROM %[1]s WHERE %[3]s.%[5]s = $%[6]d%[7]s RETURNING %[3]s.*), "+
+				"audit AS (%[8]s) SELECT %[9]s FROM %[2]s",
+			previousAlias,
+			writtenAlias,
+			table,
+			strings.Join(updateColumns, ", "),
+			r.quoteIdentifier(pkColumn),
+			paramIndex,
+			tenantCondition,
+			auditQuery,
+			r.selectList(readableColumns, columnTypes),
+		)
+		values = append(values, auditArgs...)
+	}
 
-	result, err := r.parseRowToMap(row, columns, tableName)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("item not found")
+	var result map[string]any
+	err = r.withSession(ctx, func(q db.Querier) error {
+		row := q.QueryRow(ctx, query, values...)
+
+		result, err = r.parseRowToMap(row, readableColumns, columnTypes)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return errItemNotFound
+			}
+			logrus.Errorf("failed to update item in table %s: %v", tableName, err)
+			return translateError(err, opUpdate)
 		}
-		logrus.Errorf("failed to update item in table %s: %v", tableName, err)
-		return nil, fmt.Errorf("failed to update item: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -285,245 +498,173 @@ func (r *ItemRepository) Delete(ctx context.Context, tableName string, id any) e
 		pkColumn = "id"
 	}
 
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1", r.quoteIdentifier(tableName), r.quoteIdentifier(pkColumn))
-
-	result, err := r.db.Pool.Exec(ctx, query, id)
+	scope, err := r.TenantScope(ctx, tableName)
 	if err != nil {
-		logrus.Errorf("failed to delete item from table %s: %v", tableName, err)
-		return fmt.Errorf("failed to delete item: %w", err)
+		return err
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("item not found")
-	}
-
-	logrus.Infof("successfully deleted item from table: %s", tableName)
-	return nil
-}
+	tenantCondition, tenantArgs := r.tenantCondition(scope, "", 2)
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1%s", r.quoteIdentifier(tableName), r.quoteIdentifier(pkColumn), tenantCondition)
+	args := append([]any{id}, tenantArgs...)
 
-const GetColumnTypeQuery = `
-SELECT 
-    column_name, 
-    data_type,
-    udt_name,
-    CASE
-        WHEN data_type = 'ARRAY' THEN (regexp_replace(udt_name, '^_', '') || '[]')
-        WHEN data_type = 'USER-DEFINED' THEN udt_name
-        ELSE data_type
-    END as actual_type
-FROM information_schema.columns 
-WHERE table_name = $1 AND table_schema = 'public'
-`
-
-func (r *ItemRepository) getColumnTypes(ctx context.Context, tableName string) (map[string]string, error) {
-	rows, err := r.db.Pool.Query(ctx, GetColumnTypeQuery, tableName)
-	if err != nil {
-		return nil, err
+	if r.cfg.Audit != nil {
+		auditQuery, auditArgs := r.auditInsert(ctx, tableName, pkColumn, domains.AuditOperationDelete, len(args)+1)
+		query = fmt.Sprintf("WITH %s AS (%s RETURNING *) %s", writtenAlias, query, auditQuery)
+		args = append(args, auditArgs...)
 	}
-	defer rows.Close()
 
-	columnTypes := make(map[string]string)
-	for rows.Next() {
-		var columnName, dataType, udtName, actualType string
-		if err := rows.Scan(&columnName, &dataType, &udtName, &actualType); err != nil {
-			return nil, err
+	err = r.withSession(ctx, func(q db.Querier) error {
+		result, err := q.Exec(ctx, query, args...)
+		if err != nil {
+			logrus.Errorf("failed to delete item from table %s: %v", tableName, err)
+			return translateError(err, opDelete)
 		}
-		columnTypes[columnName] = actualType
-	}
-
-	return columnTypes, nil
-}
 
-func (r *ItemRepository) shouldParseAsJSON(columnName, dataType string, value any) bool {
-	if dataType != "jsonb" {
-		return false
+		rowsAffected := result.RowsAffected()
+		if rowsAffected == 0 {
+			return errItemNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if strings.Contains(strings.ToLower(columnName), "id") {
-		return false
-	}
+	logrus.Infof("successfully deleted item from table: %s", tableName)
+	return nil
+}
 
-	if value == nil {
-		return false
-	}
+func (r *ItemRepository) GetTableSchema(ctx context.Context, tableName string) (*domains.TableInfo, error) {
+	return r.db.GetTableSchema(ctx, tableName)
+}
 
-	if strValue, ok := value.(string); ok {
-		strValue = strings.TrimSpace(strValue)
-		return strValue != "" && (strings.HasPrefix(strValue, "{") || strings.HasPrefix(strValue, "["))
-	}
+func (r *ItemRepository) ListTables(ctx context.Context) ([]string, error) {
+	return r.db.ListTables(ctx)
+}
 
-	return false
+// ListTableSummaries lists the tables the request may use. It runs in the
+// request session, so that privileges are those of the principal role.
+func (r *ItemRepository) ListTableSummaries(ctx context.Context) ([]domains.TableSummary, error) {
+	var tables []domains.TableSummary
+	err := r.withSession(ctx, func(q db.Querier) error {
+		var err error
+		tables, err = r.db.ListTableSummaries(ctx, q)
+		return err
+	})
+	return tables, err
 }
 
-func (r *ItemRepository) parseRowToMap(row pgx.Row, columns []string, tableName string) (map[string]any, error) {
-	columnTypes, err := r.getColumnTypes(context.Background(), tableName)
+// getColumnTypes maps the columns of the table to their types. Without them
+// values are still read, but dates and timestamps cannot be told apart.
+func (r *ItemRepository) getColumnTypes(ctx context.Context, tableName string) map[string]string {
+	schema, err := r.db.GetTableSchema(ctx, tableName)
 	if err != nil {
 		logrus.Warnf("could not get column types for table %s: %v", tableName, err)
-		columnTypes = make(map[string]string)
+		return map[string]string{}
 	}
 
-	values := make([]any, len(columns))
-	scanTargets := make([]any, len(columns))
-
-	for i := range values {
-		scanTargets[i] = &values[i]
+	columnTypes := make(map[string]string, len(schema.Columns))
+	for _, column := range schema.Columns {
+		columnTypes[column.Name] = column.DataType
 	}
 
-	if err := row.Scan(scanTargets...); err != nil {
-		return nil, err
-	}
+	return columnTypes
+}
 
-	result := make(map[string]any)
-	for i, column := range columns {
-		value := values[i]
-
-		processedValue := r.processColumnValue(value, column, columnTypes[column])
-
-		if r.shouldParseAsJSON(column, columnTypes[column], processedValue) {
-			if strValue, ok := processedValue.(string); ok && strValue != "" {
-				var jsonValue any
-				if err := json.Unmarshal([]byte(strValue), &jsonValue); err != nil {
-					logrus.Warnf("failed to unmarshal JSONB field %s: %v", column, err)
-					result[column] = processedValue
-				} else {
-					result[column] = jsonValue
-				}
-			} else {
-				result[column] = processedValue
+// selectList lists the columns of a read. Arrays of types pgx does not know,
+// such as enums, are read as text[].
+func (r *ItemRepository) selectList(columns []string, columnTypes map[string]string) string {
+	selectColumns := make([]string, len(columns))
+	for i, col := range columns {
+		selectColumns[i] = r.quoteIdentifier(col)
+		if dataType := columnTypes[col]; isArrayType(dataType) {
+			if _, known := builtinTypes.TypeForName(strings.TrimSuffix(dataType, "[]")); !known {
+				selectColumns[i] = fmt.Sprintf("%s::text[] AS %s", r.quoteIdentifier(col), r.quoteIdentifier(col))
 			}
-		} else {
-			result[column] = processedValue
 		}
 	}
-
-	return result, nil
+	return strings.Join(selectColumns, ", ")
 }
 
-func (r *ItemRepository) parseRowsToMap(rows pgx.Rows, columns []string, tableName string) (map[string]any, error) {
-	columnTypes, err := r.getColumnTypes(context.Background(), tableName)
-	if err != nil {
-		logrus.Warnf("could not get column types for table %s: %v", tableName, err)
-		columnTypes = make(map[string]string)
+func (r *ItemRepository) parseRowToMap(row pgx.Row, columns []string, columnTypes map[string]string) (map[string]any, error) {
+	targets := scanTargets(columns, columnTypes)
+	if err := row.Scan(targets...); err != nil {
+		return nil, err
 	}
 
-	values := make([]any, len(columns))
-	scanTargets := make([]any, len(columns))
-
-	for i := range values {
-		scanTargets[i] = &values[i]
-	}
+	return r.rowMap(columns, targets, columnTypes), nil
+}
 
-	if err := rows.Scan(scanTargets...); err != nil {
+func (r *ItemRepository) parseRowsToMap(rows pgx.Rows, columns []string, columnTypes map[string]string) (map[string]any, error) {
+	targets := scanTargets(columns, columnTypes)
+	if err := rows.Scan(targets...); err != nil {
 		return nil, err
 	}
 
-	result := make(map[string]any)
+	return r.rowMap(columns, targets, columnTypes), nil
+}
+
+// scanTargets scans arrays with their dimensions and other values in the
+// type pgx decodes them to.
+func scanTargets(columns []string, columnTypes map[string]string) []any {
+	targets := make([]any, len(columns))
 	for i, column := range columns {
-		value := values[i]
-
-		processedValue := r.processColumnValue(value, column, columnTypes[column])
-
-		if r.shouldParseAsJSON(column, columnTypes[column], processedValue) {
-			if strValue, ok := processedValue.(string); ok && strValue != "" {
-				var jsonValue any
-				if err := json.Unmarshal([]byte(strValue), &jsonValue); err != nil {
-					logrus.Warnf("failed to unmarshal JSONB field %s: %v", column, err)
-					result[column] = processedValue
-				} else {
-					result[column] = jsonValue
-				}
-			} else {
-				result[column] = processedValue
-			}
+		if isArrayType(columnTypes[column]) {
+			targets[i] = &pgtype.Array[any]{}
 		} else {
-			result[column] = processedValue
+			targets[i] = new(any)
 		}
 	}
-
-	return result, nil
+	return targets
 }
 
-func (r *ItemRepository) processColumnValue(value any, columnName, dataType string) any {
-	if value == nil {
-		return nil
-	}
-
-	if strings.HasSuffix(dataType, "[]") {
-		return r.parsePostgreSQLArray(value)
-	}
-
-	switch v := value.(type) {
-	case string:
-		return utils.ConvertStringToAppropriateType(v, dataType)
-	case []byte:
-		if dataType == "uuid" {
-			return string(v)
+func (r *ItemRepository) rowMap(columns []string, targets []any, columnTypes map[string]string) map[string]any {
+	result := make(map[string]any, len(columns))
+	for i, column := range columns {
+		switch target := targets[i].(type) {
+		case *pgtype.Array[any]:
+			result[column] = r.arrayValue(*target, columnTypes[column])
+		case *any:
+			result[column] = r.columnValue(*target, columnTypes[column])
 		}
-		strValue := string(v)
-		return utils.ConvertStringToAppropriateType(strValue, dataType)
-	default:
-		return utils.ConvertValue(v)
 	}
+	return result
 }
 
-func (r *ItemRepository) parsePostgreSQLArray(value any) any {
-	if value == nil {
-		return nil
-	}
-
-	var strValue string
-	switch v := value.(type) {
-	case string:
-		strValue = v
-	case []byte:
-		strValue = string(v)
-	default:
-		return value
+// columnParam converts a value given for a column to a query parameter. Lists
+// given for array columns are sent as array literals, and JSON numbers as text
+// that PostgreSQL parses without losing digits.
+func columnParam(column string, value any, columnTypes map[string]string) (any, error) {
+	if number, ok := value.(json.Number); ok {
+		return number.String(), nil
 	}
 
-	if strValue == "{}" || strValue == "" {
-		return []any{}
+	list, ok := value.([]any)
+	if !ok || !isArrayType(columnTypes[column]) {
+		return value, nil
 	}
 
-	if strings.HasPrefix(strValue, "{") && strings.HasSuffix(strValue, "}") {
-		strValue = strValue[1 : len(strValue)-1]
+	literal, err := arrayLiteral(list, columnTypes[column])
+	if err != nil {
+		return nil, &domains.ValidationError{Fields: []domains.FieldError{{Field: column, Message: err.Error()}}}
 	}
+	return literal, nil
+}
 
-	var elements []string
-	var current strings.Builder
-	inQuotes := false
-	escaped := false
-
-	for _, char := range strValue {
-		switch {
-		case escaped:
-			current.WriteRune(char)
-			escaped = false
-		case char == '\\':
-			escaped = true
-			current.WriteRune(char)
-		case char == '"':
-			inQuotes = !inQuotes
-		case char == ',' && !inQuotes:
-			element := strings.TrimSpace(current.String())
-			if element != "" {
-				elements = append(elements, element)
-			}
-			current.Reset()
-		default:
-			current.WriteRune(char)
+// readableColumns lists the columns of the table that are not hidden. Tables
+// without any cannot be read or written, since there would be nothing to
+// return.
+func (r *ItemRepository) readableColumns(tableName string, columns []string) ([]string, error) {
+	readable := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if !r.cfg.IsColumnHidden(tableName, col) {
+			readable = append(readable, col)
 		}
 	}
-
-	if current.Len() > 0 {
-		element := strings.TrimSpace(current.String())
-		if element != "" {
-			elements = append(elements, element)
-		}
+	if len(readable) == 0 {
+		return nil, domains.NewError(domains.ErrCodeNotFound, fmt.Sprintf("table '%s' has no readable columns", tableName))
 	}
-
-	return elements
+	return readable, nil
 }
 
 func (r *ItemRepository) columnExists(columns []string, column string) bool {
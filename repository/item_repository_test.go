@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"reflect"
+	"testing"
+)
+
+func TestReadableColumns(t *testing.T) {
+	r := &ItemRepository{cfg: &config.GenApiConfig{Tables: map[string]config.TableConfig{
+		"users": {Columns: map[string]config.ColumnPolicy{"password_hash": {Hidden: true}}},
+		"secrets": {Columns: map[string]config.ColumnPolicy{
+			"id":    {Hidden: true},
+			"value": {Hidden: true},
+		}},
+	}}}
+
+	tests := []struct {
+		name    string
+		table   string
+		columns []string
+		want    []string
+	}{
+		{name: "no policies", table: "posts", columns: []string{"id", "title"}, want: []string{"id", "title"}},
+		{name: "hidden column", table: "users", columns: []string{"id", "password_hash", "name"}, want: []string{"id", "name"}},
+		{name: "every column hidden", table: "secrets", columns: []string{"id", "value"}},
+		{name: "no columns", table: "posts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.readableColumns(tt.table, tt.columns)
+			if tt.want == nil {
+				var domainErr *domains.Error
+				if !errors.As(err, &domainErr) || domainErr.Code != domains.ErrCodeNotFound {
+					t.Fatalf("readableColumns() = %v, %v, want not_found", got, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readableColumns() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectList(t *testing.T) {
+	r := &ItemRepository{}
+
+	tests := []struct {
+		name        string
+		columns     []string
+		columnTypes map[string]string
+		want        string
+	}{
+		{name: "plain columns", columns: []string{"id", "name"}, want: `"id", "name"`},
+		{name: "builtin array", columns: []string{"tags"}, columnTypes: map[string]string{"tags": "text[]"}, want: `"tags"`},
+		{name: "enum array", columns: []string{"moods"}, columnTypes: map[string]string{"moods": "mood[]"}, want: `"moods"::text[] AS "moods"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.selectList(tt.columns, tt.columnTypes); got != tt.want {
+				t.Errorf("selectList() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
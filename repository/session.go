@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/repository/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sirupsen/logrus"
+)
+
+const pgInvalidParameterValue = "22023"
+
+// withSession runs fn directly on the pool, or inside a transaction that
+// carries the per-request session settings when any are configured.
+func (r *ItemRepository) withSession(ctx context.Context, fn func(q db.Querier) error) error {
+	if r.cfg.RowLevelSecurity == nil && domains.SchemaFromContext(ctx) == "" {
+		return fn(r.db.Pool)
+	}
+
+	return r.inTransaction(ctx, func(tx pgx.Tx) error {
+		return fn(tx)
+	})
+}
+
+// inTransaction runs fn inside a transaction that carries the per-request
+// session settings. Errors returned by fn are passed through untranslated.
+func (r *ItemRepository) inTransaction(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	var innerErr error
+	err := pgx.BeginFunc(ctx, r.db.Pool, func(tx pgx.Tx) error {
+		if innerErr = r.applySessionSettings(ctx, tx); innerErr != nil {
+			return innerErr
+		}
+		innerErr = fn(tx)
+		return innerErr
+	})
+	if innerErr != nil {
+		return innerErr
+	}
+	if err != nil {
+		logrus.Errorf("request transaction failed: %v", err)
+		return translateError(err, "run request transaction")
+	}
+
+	return nil
+}
+
+func (r *ItemRepository) applySessionSettings(ctx context.Context, tx pgx.Tx) error {
+	if schema := domains.SchemaFromContext(ctx); schema != "" {
+		if _, err := tx.Exec(ctx, "SELECT set_config('search_path', $1, true)", pgx.Identifier{schema}.Sanitize()); err != nil {
+			logrus.Errorf("failed to set search path: %v", err)
+			return translateError(err, "set search path")
+		}
+	}
+
+	if r.cfg.RowLevelSecurity == nil {
+		return nil
+	}
+
+	principal := domains.PrincipalFromContext(ctx)
+
+	claims := map[string]any{}
+	if principal != nil && principal.Claims != nil {
+		claims = principal.Claims
+	}
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, "SELECT set_config($1, $2, true)", r.cfg.RowLevelSecurity.GetClaimsSetting(), string(encodedClaims)); err != nil {
+		logrus.Errorf("failed to set request claims: %v", err)
+		return translateError(err, "set request claims")
+	}
+
+	role, err := r.sessionRole(principal)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, "SET LOCAL ROLE "+pgx.Identifier{role}.Sanitize()); err != nil {
+		logrus.Warnf("failed to switch to role %s: %v", role, err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgInvalidParameterValue {
+			return &domains.Error{Code: domains.ErrCodePermissionDenied, Message: fmt.Sprintf("role %s is not available", role), Err: err}
+		}
+		return translateError(err, "switch role")
+	}
+
+	return nil
+}
+
+// sessionRole returns the role a request runs as. Principals without a role
+// fall back to the configured default role, and then to the anonymous role.
+// Requests never run as the owner of the pool, which bypasses row-level
+// security.
+func (r *ItemRepository) sessionRole(principal *domains.Principal) (string, error) {
+	if principal != nil && principal.Role != "" {
+		return principal.Role, nil
+	}
+	if role := r.cfg.RowLevelSecurity.DefaultRole; role != "" {
+		return role, nil
+	}
+	if r.cfg.Auth != nil && r.cfg.Auth.AnonymousRole != "" {
+		return r.cfg.Auth.AnonymousRole, nil
+	}
+	return "", domains.NewError(domains.ErrCodePermissionDenied, "the request has no role to run as")
+}
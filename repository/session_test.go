@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"testing"
+)
+
+func TestSessionRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaultRole string
+		auth        *config.AuthConfig
+		principal   *domains.Principal
+		want        string
+	}{
+		{name: "principal role", defaultRole: "web_anon", principal: &domains.Principal{Role: "web_user"}, want: "web_user"},
+		{name: "empty role uses default role", defaultRole: "web_anon", principal: &domains.Principal{Subject: "1"}, want: "web_anon"},
+		{name: "no principal uses default role", defaultRole: "web_anon", want: "web_anon"},
+		{name: "empty role uses anonymous role", auth: &config.AuthConfig{AnonymousRole: "anon"}, principal: &domains.Principal{Subject: "1"}, want: "anon"},
+		{name: "default role wins over anonymous role", defaultRole: "web_anon", auth: &config.AuthConfig{AnonymousRole: "anon"}, want: "web_anon"},
+		{name: "empty role without fallback is rejected", principal: &domains.Principal{Subject: "1"}},
+		{name: "no principal without fallback is rejected", auth: &config.AuthConfig{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ItemRepository{cfg: &config.GenApiConfig{
+				Auth:             tt.auth,
+				RowLevelSecurity: &config.RowLevelSecurityConfig{DefaultRole: tt.defaultRole},
+			}}
+
+			role, err := r.sessionRole(tt.principal)
+			if tt.want == "" {
+				var domainErr *domains.Error
+				if !errors.As(err, &domainErr) || domainErr.Code != domains.ErrCodePermissionDenied {
+					t.Fatalf("sessionRole() = %q, %v, want permission_denied", role, err)
+				}
+				if role != "" {
+					t.Fatalf("sessionRole() = %q, want no role so the pool owner is never used", role)
+				}
+				return
+			}
+			if err != nil || role != tt.want {
+				t.Fatalf("sessionRole() = %q, %v, want %q", role, err, tt.want)
+			}
+		})
+	}
+}
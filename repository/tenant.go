@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+)
+
+// TenantScope returns the tenant restriction of tableName, or nil when
+// tenancy is off, tenants are routed to their own schema or the table has no
+// tenant column.
+func (r *ItemRepository) TenantScope(ctx context.Context, tableName string) (*domains.TenantScope, error) {
+	if r.cfg.Tenancy == nil || r.cfg.Tenancy.SchemaPerTenant {
+		return nil, nil
+	}
+
+	schema, err := r.db.GetTableSchema(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	column := r.cfg.Tenancy.GetColumn()
+	scoped := false
+	for _, col := range schema.Columns {
+		if col.Name == column {
+			scoped = true
+			break
+		}
+	}
+	if !scoped {
+		return nil, nil
+	}
+
+	tenant := domains.TenantFromContext(ctx)
+	if tenant == "" {
+		return nil, domains.NewError(domains.ErrCodeBadRequest, "tenant is required")
+	}
+	return &domains.TenantScope{Column: column, Value: tenant}, nil
+}
+
+// tenantCondition returns the " AND <column> = $n" suffix restricting a
+// WHERE clause to the tenant, or an empty string when scope is nil. The
+// qualifier, when set, prefixes the column.
+func (r *ItemRepository) tenantCondition(scope *domains.TenantScope, qualifier string, argIndex int) (string, []any) {
+	if scope == nil {
+		return "", nil
+	}
+
+	column := r.quoteIdentifier(scope.Column)
+	if qualifier != "" {
+		column = qualifier + "." + column
+	}
+	return fmt.Sprintf(" AND %s = $%d", column, argIndex), []any{scope.Value}
+}
+
+// withTenant returns a copy of data carrying the tenant of scope.
+func withTenant(data map[string]any, scope *domains.TenantScope) map[string]any {
+	scoped := make(map[string]any, len(data)+1)
+	for column, value := range data {
+		scoped[column] = value
+	}
+	scoped[scope.Column] = scope.Value
+	return scoped
+}
+
+// auditedTableName names a table in the audit log. Tables of tenant schemas
+// are qualified with their schema so that the entries of tenants sharing
+// table names and keys stay apart.
+func auditedTableName(ctx context.Context, tableName string) string {
+	if schema := domains.SchemaFromContext(ctx); schema != "" {
+		return schema + "." + tableName
+	}
+	return tableName
+}
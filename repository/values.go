@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// localTimestampLayout formats timestamps without time zone, which have no
+// offset to write.
+const localTimestampLayout = "2006-01-02T15:04:05.999999999"
+
+// rangeElementTypes maps the built-in range types to the type of their bounds.
+var rangeElementTypes = map[string]string{
+	"int4range": "integer",
+	"int8range": "bigint",
+	"numrange":  "numeric",
+	"tsrange":   "timestamp without time zone",
+	"tstzrange": "timestamp with time zone",
+	"daterange": "date",
+}
+
+// columnValue converts a value scanned by pgx to its value in a row. dataType
+// is the type of the column as reported by the schema:
+//
+//   - timestamps with time zone are RFC 3339 strings in UTC, timestamps
+//     without time zone lack the offset and dates are written as 2006-01-02
+//   - numeric values are exact JSON numbers, or strings with NumericAsString
+//   - bigint values are numbers, or strings with BigIntAsString
+//   - NaN and infinite values are the strings PostgreSQL writes for them
+//   - intervals are ISO 8601 durations and ranges are range literals whose
+//     bounds are formatted like their type
+//   - bytea values are hex strings as in PostgreSQL, "\x0102"
+//
+// Types pgx does not decode are read as text.
+func (r *ItemRepository) columnValue(value any, dataType string) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		if r.cfg.BigIntAsString {
+			return strconv.FormatInt(v, 10)
+		}
+		return v
+	case float32:
+		// Formatting with 32 bit precision avoids the digits float64 adds.
+		number, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+		return floatValue(number)
+	case float64:
+		return floatValue(v)
+	case pgtype.Numeric:
+		text, err := v.Value()
+		if err != nil {
+			return nil
+		}
+		if v.NaN || v.InfinityModifier != pgtype.Finite || r.cfg.NumericAsString {
+			return text
+		}
+		return json.Number(text.(string))
+	case time.Time:
+		switch dataType {
+		case "date":
+			return v.Format(time.DateOnly)
+		case "timestamp without time zone":
+			return v.Format(localTimestampLayout)
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case pgtype.InfinityModifier:
+		return v.String()
+	case pgtype.Time:
+		return timeOfDay(v.Microseconds)
+	case pgtype.Interval:
+		return isoDuration(v)
+	case pgtype.Range[any]:
+		return r.rangeLiteral(v, rangeElementTypes[dataType])
+	case pgtype.Multirange[pgtype.Range[any]]:
+		elementType := rangeElementTypes[strings.Replace(dataType, "multirange", "range", 1)]
+		literals := make([]string, len(v))
+		for i, element := range v {
+			literals[i] = r.rangeLiteral(element, elementType)
+		}
+		return "{" + strings.Join(literals, ",") + "}"
+	case [16]byte:
+		return fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16])
+	case []byte:
+		return `\x` + hex.EncodeToString(v)
+	case netip.Prefix:
+		if dataType == "inet" && v.IsSingleIP() {
+			return v.Addr().String()
+		}
+		return v.String()
+	case net.HardwareAddr:
+		return v.String()
+	case string, bool, json.Number, map[string]any, []any:
+		return v
+	}
+
+	// Geometric types and bit strings write their text form.
+	if valuer, ok := value.(driver.Valuer); ok {
+		if text, err := valuer.Value(); err == nil {
+			if text, ok := text.(string); ok {
+				return text
+			}
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// floatValue writes the values JSON has no number for as PostgreSQL does.
+func floatValue(v float64) any {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	return v
+}
+
+// timeOfDay formats a time of day given in microseconds since midnight.
+func timeOfDay(microseconds int64) string {
+	text := fmt.Sprintf("%02d:%02d:%02d", microseconds/3600e6, microseconds/60e6%60, microseconds/1e6%60)
+	if fraction := microseconds % 1e6; fraction != 0 {
+		text += strings.TrimRight(fmt.Sprintf(".%06d", fraction), "0")
+	}
+	return text
+}
+
+// isoDuration formats an interval as an ISO 8601 duration the way PostgreSQL
+// does with IntervalStyle iso_8601, every component carries its own sign.
+func isoDuration(v pgtype.Interval) string {
+	var text strings.Builder
+	text.WriteByte('P')
+	for _, part := range []struct {
+		value int64
+		unit  byte
+	}{{int64(v.Months / 12), 'Y'}, {int64(v.Months % 12), 'M'}, {int64(v.Days), 'D'}} {
+		if part.value != 0 {
+			fmt.Fprintf(&text, "%d%c", part.value, part.unit)
+		}
+	}
+
+	if microseconds := v.Microseconds; microseconds != 0 {
+		text.WriteByte('T')
+		if hours := microseconds / 3600e6; hours != 0 {
+			fmt.Fprintf(&text, "%dH", hours)
+		}
+		if minutes := microseconds / 60e6 % 60; minutes != 0 {
+			fmt.Fprintf(&text, "%dM", minutes)
+		}
+		if seconds := microseconds % 60e6; seconds != 0 {
+			if seconds < 0 {
+				text.WriteByte('-')
+				seconds = -seconds
+			}
+			fmt.Fprintf(&text, "%d", seconds/1e6)
+			if fraction := seconds % 1e6; fraction != 0 {
+				text.WriteString(strings.TrimRight(fmt.Sprintf(".%06d", fraction), "0"))
+			}
+			text.WriteByte('S')
+		}
+	}
+
+	if text.Len() == 1 {
+		return "PT0S"
+	}
+	return text.String()
+}
+
+// rangeLiteral formats a range like PostgreSQL, with the bounds formatted as
+// values of elementType.
+func (r *ItemRepository) rangeLiteral(v pgtype.Range[any], elementType string) string {
+	if v.LowerType == pgtype.Empty {
+		return "empty"
+	}
+
+	var text strings.Builder
+	if v.LowerType == pgtype.Inclusive {
+		text.WriteByte('[')
+	} else {
+		text.WriteByte('(')
+	}
+	if v.LowerType != pgtype.Unbounded {
+		text.WriteString(r.rangeBound(v.Lower, elementType))
+	}
+	text.WriteByte(',')
+	if v.UpperType != pgtype.Unbounded {
+		text.WriteString(r.rangeBound(v.Upper, elementType))
+	}
+	if v.UpperType == pgtype.Inclusive {
+		text.WriteByte(']')
+	} else {
+		text.WriteByte(')')
+	}
+	return text.String()
+}
+
+// rangeBound formats a bound of a range literal, quoting it when needed. An
+// empty bound is quoted, unquoted it would make the range unbounded.
+func (r *ItemRepository) rangeBound(value any, elementType string) string {
+	text := fmt.Sprint(r.columnValue(value, elementType))
+	if text == "" || strings.ContainsAny(text, `,()[]"\`) || strings.ContainsFunc(text, unicode.IsSpace) {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+	}
+	return text
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"encoding/json"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestColumnValue(t *testing.T) {
+	plus5 := time.FixedZone("+05:00", 5*60*60)
+
+	tests := []struct {
+		name     string
+		cfg      config.GenApiConfig
+		value    any
+		dataType string
+		want     any
+	}{
+		{name: "null", value: nil, dataType: "text", want: nil},
+		{name: "smallint", value: int16(-3), dataType: "smallint", want: int64(-3)},
+		{name: "integer", value: int32(7), dataType: "integer", want: int64(7)},
+		{name: "bigint", value: int64(math.MaxInt64), dataType: "bigint", want: int64(math.MaxInt64)},
+		{name: "bigint as string", cfg: config.GenApiConfig{BigIntAsString: true}, value: int64(math.MaxInt64), dataType: "bigint", want: "9223372036854775807"},
+		{name: "integer with bigint as string", cfg: config.GenApiConfig{BigIntAsString: true}, value: int32(7), dataType: "integer", want: int64(7)},
+		{name: "real", value: float32(1.1), dataType: "real", want: 1.1},
+		{name: "double precision", value: 0.1, dataType: "double precision", want: 0.1},
+		{name: "real NaN", value: float32(math.NaN()), dataType: "real", want: "NaN"},
+		{name: "double precision infinity", value: math.Inf(1), dataType: "double precision", want: "Infinity"},
+		{name: "double precision negative infinity", value: math.Inf(-1), dataType: "double precision", want: "-Infinity"},
+		{name: "numeric", value: pgtype.Numeric{Int: big.NewInt(12345678901234567), Exp: -2, Valid: true}, dataType: "numeric", want: json.Number("123456789012345.67")},
+		{name: "numeric as string", cfg: config.GenApiConfig{NumericAsString: true}, value: pgtype.Numeric{Int: big.NewInt(5), Exp: -1, Valid: true}, dataType: "numeric", want: "0.5"},
+		{name: "numeric NaN", value: pgtype.Numeric{NaN: true, Valid: true}, dataType: "numeric", want: "NaN"},
+		{name: "numeric infinity", value: pgtype.Numeric{InfinityModifier: pgtype.Infinity, Valid: true}, dataType: "numeric", want: "Infinity"},
+		{name: "numeric negative infinity", value: pgtype.Numeric{InfinityModifier: pgtype.NegativeInfinity, Valid: true}, dataType: "numeric", want: "-Infinity"},
+		{name: "timestamptz in UTC", value: time.Date(2026, 1, 2, 3, 4, 5, 600000000, time.UTC), dataType: "timestamp with time zone", want: "2026-01-02T03:04:05.6Z"},
+		{name: "timestamptz in local time", value: time.Date(2026, 1, 2, 3, 4, 5, 0, plus5), dataType: "timestamp with time zone", want: "2026-01-01T22:04:05Z"},
+		{name: "timestamp", value: time.Date(2026, 1, 2, 3, 4, 5, 123000, time.UTC), dataType: "timestamp without time zone", want: "2026-01-02T03:04:05.000123"},
+		{name: "timestamp keeps local time", value: time.Date(2026, 1, 2, 3, 4, 5, 0, plus5), dataType: "timestamp without time zone", want: "2026-01-02T03:04:05"},
+		{name: "date", value: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), dataType: "date", want: "2026-01-02"},
+		{name: "timestamp infinity", value: pgtype.Infinity, dataType: "timestamp with time zone", want: "infinity"},
+		{name: "date negative infinity", value: pgtype.NegativeInfinity, dataType: "date", want: "-infinity"},
+		{name: "time", value: pgtype.Time{Microseconds: 3723000500, Valid: true}, dataType: "time without time zone", want: "01:02:03.0005"},
+		{name: "midnight", value: pgtype.Time{Valid: true}, dataType: "time without time zone", want: "00:00:00"},
+		{name: "interval", value: pgtype.Interval{Months: 14, Days: 3, Microseconds: 14706500000, Valid: true}, dataType: "interval", want: "P1Y2M3DT4H5M6.5S"},
+		{
+			name:     "int4range",
+			value:    pgtype.Range[any]{Lower: int32(1), Upper: int32(10), LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+			dataType: "int4range",
+			want:     "[1,10)",
+		},
+		{
+			name: "int4multirange",
+			value: pgtype.Multirange[pgtype.Range[any]]{
+				{Lower: int32(1), Upper: int32(3), LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+				{Lower: int32(5), LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true},
+			},
+			dataType: "int4multirange",
+			want:     "{[1,3),[5,)}",
+		},
+		{name: "empty multirange", value: pgtype.Multirange[pgtype.Range[any]]{}, dataType: "datemultirange", want: "{}"},
+		{name: "uuid", value: [16]byte{0x0b, 0x1e, 0x5a, 0x9c, 0x2f, 0x6d, 0x4c, 0x8e, 0x9a, 0x3b, 0x7d, 0x4e, 0x5f, 0x6a, 0x7b, 0x8c}, dataType: "uuid", want: "0b1e5a9c-2f6d-4c8e-9a3b-7d4e5f6a7b8c"},
+		{name: "bytea", value: []byte{0x01, 0xab}, dataType: "bytea", want: `\x01ab`},
+		{name: "empty bytea", value: []byte{}, dataType: "bytea", want: `\x`},
+		{name: "inet address", value: netip.MustParsePrefix("192.168.0.1/32"), dataType: "inet", want: "192.168.0.1"},
+		{name: "inet network", value: netip.MustParsePrefix("192.168.0.0/24"), dataType: "inet", want: "192.168.0.0/24"},
+		{name: "cidr", value: netip.MustParsePrefix("10.0.0.1/32"), dataType: "cidr", want: "10.0.0.1/32"},
+		{name: "macaddr", value: net.HardwareAddr{0x08, 0x00, 0x2b, 0x01, 0x02, 0x03}, dataType: "macaddr", want: "08:00:2b:01:02:03"},
+		{name: "point", value: pgtype.Point{P: pgtype.Vec2{X: 1.5, Y: -2}, Valid: true}, dataType: "point", want: "(1.5,-2)"},
+		{name: "jsonb", value: map[string]any{"a": []any{float64(1)}}, dataType: "jsonb", want: map[string]any{"a": []any{float64(1)}}},
+		{name: "text", value: "a", dataType: "text", want: "a"},
+		{name: "boolean", value: true, dataType: "boolean", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ItemRepository{cfg: &tt.cfg}
+			if got := r.columnValue(tt.value, tt.dataType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columnValue(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// The durations are what PostgreSQL writes for the intervals with
+// IntervalStyle iso_8601.
+func TestISODuration(t *testing.T) {
+	tests := []struct {
+		interval string
+		value    pgtype.Interval
+		want     string
+	}{
+		{interval: "0", value: pgtype.Interval{}, want: "PT0S"},
+		{interval: "1 year 2 months 3 days 04:05:06.5", value: pgtype.Interval{Months: 14, Days: 3, Microseconds: 14706500000}, want: "P1Y2M3DT4H5M6.5S"},
+		{interval: "1 year -1 month", value: pgtype.Interval{Months: 11}, want: "P11M"},
+		{interval: "-1 year -2 months", value: pgtype.Interval{Months: -14}, want: "P-1Y-2M"},
+		{interval: "-1 day +02:00", value: pgtype.Interval{Days: -1, Microseconds: 7200000000}, want: "P-1DT2H"},
+		{interval: "-01:30", value: pgtype.Interval{Microseconds: -5400000000}, want: "PT-1H-30M"},
+		{interval: "-01:00:30", value: pgtype.Interval{Microseconds: -3630000000}, want: "PT-1H-30S"},
+		{interval: "-1.5 seconds", value: pgtype.Interval{Microseconds: -1500000}, want: "PT-1.5S"},
+		{interval: "-0.5 seconds", value: pgtype.Interval{Microseconds: -500000}, want: "PT-0.5S"},
+		{interval: "1 microsecond", value: pgtype.Interval{Microseconds: 1}, want: "PT0.000001S"},
+		{interval: "100 hours", value: pgtype.Interval{Microseconds: 360000000000}, want: "PT100H"},
+		{interval: "7 days", value: pgtype.Interval{Days: 7}, want: "P7D"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			if got := isoDuration(tt.value); got != tt.want {
+				t.Errorf("isoDuration(%s) = %s, want %s", tt.interval, got, tt.want)
+			}
+		})
+	}
+}
+
+// Ranges are written as PostgreSQL writes them, except that timestamp bounds
+// are formatted like timestamp columns.
+func TestRangeLiteral(t *testing.T) {
+	r := &ItemRepository{cfg: &config.GenApiConfig{}}
+
+	tests := []struct {
+		name        string
+		value       pgtype.Range[any]
+		elementType string
+		want        string
+	}{
+		{name: "empty", value: pgtype.Range[any]{LowerType: pgtype.Empty, UpperType: pgtype.Empty, Valid: true}, elementType: "integer", want: "empty"},
+		{name: "int4range", value: pgtype.Range[any]{Lower: int32(1), Upper: int32(10), LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true}, elementType: "integer", want: "[1,10)"},
+		{name: "unbounded", value: pgtype.Range[any]{LowerType: pgtype.Unbounded, UpperType: pgtype.Unbounded, Valid: true}, elementType: "integer", want: "(,)"},
+		{
+			name:        "numrange",
+			value:       pgtype.Range[any]{Lower: pgtype.Numeric{Int: big.NewInt(15), Exp: -1, Valid: true}, LowerType: pgtype.Exclusive, UpperType: pgtype.Unbounded, Valid: true},
+			elementType: "numeric",
+			want:        "(1.5,)",
+		},
+		{
+			name:        "inclusive upper bound",
+			value:       pgtype.Range[any]{Lower: int64(-5), Upper: int64(5), LowerType: pgtype.Exclusive, UpperType: pgtype.Inclusive, Valid: true},
+			elementType: "bigint",
+			want:        "(-5,5]",
+		},
+		{
+			name:        "daterange",
+			value:       pgtype.Range[any]{Lower: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Upper: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+			elementType: "date",
+			want:        "[2026-01-02,2026-01-05)",
+		},
+		{
+			name:        "tstzrange",
+			value:       pgtype.Range[any]{Lower: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true},
+			elementType: "timestamp with time zone",
+			want:        "[2026-01-02T03:04:05Z,)",
+		},
+		{
+			name:        "infinite bound",
+			value:       pgtype.Range[any]{Lower: pgtype.NegativeInfinity, Upper: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+			elementType: "date",
+			want:        "[-infinity,2026-01-02)",
+		},
+		{
+			name:        "quoted bounds",
+			value:       pgtype.Range[any]{Lower: `a "b"`, Upper: `c\d`, LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+			elementType: "text",
+			want:        `["a \"b\"","c\\d")`,
+		},
+		{
+			name:        "bound with separator",
+			value:       pgtype.Range[any]{Lower: "a,b", Upper: "c)", LowerType: pgtype.Inclusive, UpperType: pgtype.Inclusive, Valid: true},
+			elementType: "text",
+			want:        `["a,b","c)"]`,
+		},
+		{
+			name:        "empty bound",
+			value:       pgtype.Range[any]{Lower: "", Upper: "b", LowerType: pgtype.Inclusive, UpperType: pgtype.Exclusive, Valid: true},
+			elementType: "text",
+			want:        `["",b)`,
+		},
+		{
+			name:        "bound with whitespace",
+			value:       pgtype.Range[any]{Lower: "a\tb", LowerType: pgtype.Inclusive, UpperType: pgtype.Unbounded, Valid: true},
+			elementType: "text",
+			want:        "[\"a\tb\",)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.rangeLiteral(tt.value, tt.elementType); got != tt.want {
+				t.Errorf("rangeLiteral() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnParam(t *testing.T) {
+	columnTypes := map[string]string{"id": "bigint", "amount": "numeric", "tags": "text[]", "meta": "jsonb"}
+
+	tests := []struct {
+		name   string
+		column string
+		value  any
+		want   any
+	}{
+		{name: "bigint beyond 2^53", column: "id", value: json.Number("9007199254740993"), want: "9007199254740993"},
+		{name: "numeric", column: "amount", value: json.Number("12345678901234567890.123"), want: "12345678901234567890.123"},
+		{name: "string", column: "tags", value: "a", want: "a"},
+		{name: "array", column: "tags", value: []any{"a", nil}, want: `{"a",NULL}`},
+		{name: "json list", column: "meta", value: []any{"a"}, want: []any{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := columnParam(tt.column, tt.value, columnTypes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columnParam() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// A bigint beyond 2^53 is written as text and read back as an int64, which
+// JSON encodes with every digit.
+func TestBigIntRoundTrip(t *testing.T) {
+	r := &ItemRepository{cfg: &config.GenApiConfig{}}
+
+	param, err := columnParam("id", json.Number("9007199254740993"), map[string]string{"id": "bigint"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := strconv.ParseInt(param.(string), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := json.Marshal(r.columnValue(stored, "bigint"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != "9007199254740993" {
+		t.Errorf("read back %s, want 9007199254740993", encoded)
+	}
+}
@@ -1,41 +1,163 @@
 package router
 
 import (
+	"context"
 	"errors"
 	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/grpcserver"
 	"github.com/abdulaziz-go/go-gen-apis/handler"
+	"github.com/abdulaziz-go/go-gen-apis/middleware"
 	"github.com/abdulaziz-go/go-gen-apis/repository"
 	"github.com/abdulaziz-go/go-gen-apis/repository/db"
 	"github.com/abdulaziz-go/go-gen-apis/service"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"net"
+	"net/http"
 )
 
+// Shutdown stops the gRPC server, waiting for pending calls until ctx is
+// done, and closes the database pool.
+type Shutdown func(ctx context.Context) error
+
+// SetUpAutoGeneratedApis registers the generated routes on ginEngine. The
+// gRPC server it may start runs until the process exits, use SetUp to stop
+// it.
 func SetUpAutoGeneratedApis(cfg *config.GenApiConfig, ginEngine *gin.RouterGroup) error {
+	_, err := SetUp(cfg, ginEngine)
+	return err
+}
+
+// SetUp registers the generated routes on ginEngine and starts the gRPC
+// server when configured. Call the returned Shutdown once the HTTP server
+// has stopped.
+func SetUp(cfg *config.GenApiConfig, ginEngine *gin.RouterGroup) (Shutdown, error) {
 	if cfg == nil {
 		logrus.Error("cfg is nil")
-		return errors.New("gen api cfg is nil")
+		return nil, errors.New("gen api cfg is nil")
 	}
 
 	database, err := db.NewConnection(cfg)
 	if err != nil {
 		logrus.Errorf("failed to connecting postgres %v", err)
-		return err
+		return nil, err
 	}
 
-	repo := repository.NewItemRepository(database)
-	itemService := service.NewItemService(repo)
-	itemHandler := handler.NewItemHandler(itemService)
-	setupItemRoutes(ginEngine, itemHandler)
-	return nil
+	grpcServer, err := setUpRoutes(cfg, ginEngine, database)
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		defer database.Close()
+		if grpcServer != nil {
+			return grpcServer.Shutdown(ctx)
+		}
+		return nil
+	}, nil
+}
+
+// setUpRoutes registers the routes and returns the gRPC server it started, if
+// any.
+func setUpRoutes(cfg *config.GenApiConfig, ginEngine *gin.RouterGroup, database *db.DB) (*grpcserver.Server, error) {
+	var apiKeyService *service.APIKeyService
+	if cfg.Auth != nil && cfg.Auth.APIKeys != nil {
+		apiKeyRepo := repository.NewAPIKeyRepository(database, cfg.Auth.APIKeys.GetTable())
+		if err := apiKeyRepo.EnsureTable(context.Background()); err != nil {
+			return nil, err
+		}
+		apiKeyService = service.NewAPIKeyService(apiKeyRepo, cfg)
+	}
+
+	authenticator, err := middleware.NewRequestAuthenticator(cfg, apiKeyService)
+	if err != nil {
+		logrus.Errorf("failed to configure authentication: %v", err)
+		return nil, err
+	}
+
+	tenantResolver, err := middleware.NewTenantResolver(cfg)
+	if err != nil {
+		logrus.Errorf("failed to configure tenancy: %v", err)
+		return nil, err
+	}
+
+	repo := repository.NewItemRepository(database, cfg)
+	if cfg.History != nil {
+		if err := repo.EnsureHistoryTables(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	itemService := service.NewItemService(repo, cfg)
+	itemHandler := handler.NewItemHandler(itemService, cfg)
+
+	var auditRepo *repository.AuditRepository
+	if cfg.Audit != nil {
+		auditRepo = repository.NewAuditRepository(database, cfg.Audit.GetTable(), cfg.RowLevelSecurity != nil)
+		if err := auditRepo.EnsureTable(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	apiGroup := ginEngine.Group("")
+	apiGroup.Use(middleware.RequestID())
+	if authenticator != nil {
+		apiGroup.Use(middleware.Authenticate(authenticator))
+	}
+
+	// dataGroup serves the routes reading or writing table rows, they are
+	// scoped to the tenant of the request.
+	dataGroup := apiGroup.Group("")
+	if tenantResolver != nil {
+		dataGroup.Use(middleware.ResolveTenant(tenantResolver))
+	}
+
+	// The limited groups take a token for every request, the limits of the
+	// tables apply to the routes naming one.
+	limitedGroup, limitedDataGroup, graphQLGroup := apiGroup, dataGroup, dataGroup
+	rateLimiter := middleware.NewRateLimiter(cfg, itemService)
+	if rateLimiter != nil {
+		limitedGroup = apiGroup.Group("", rateLimiter.Handler())
+		limitedDataGroup = dataGroup.Group("", rateLimiter.Handler())
+		// GraphQL takes its tokens per root field, by table and operation.
+		graphQLGroup = dataGroup.Group("", rateLimiter.Attach())
+	}
+
+	setupItemRoutes(limitedDataGroup, itemHandler)
+	if apiKeyService != nil {
+		setupAPIKeyRoutes(limitedGroup, handler.NewAPIKeyHandler(apiKeyService))
+	}
+	if auditRepo != nil {
+		setupAuditRoutes(limitedDataGroup, handler.NewAuditHandler(service.NewAuditService(auditRepo, itemService)))
+	}
+	metadataService := service.NewMetadataService(itemService, cfg)
+	setupMetadataRoutes(limitedDataGroup, handler.NewMetadataHandler(metadataService))
+	if cfg.GraphQL != nil {
+		setupGraphQLRoutes(graphQLGroup, handler.NewGraphQLHandler(service.NewGraphQLService(itemService, cfg)))
+	}
+	if cfg.OpenAPI != nil {
+		openAPIHandler := handler.NewOpenAPIHandler(service.NewOpenAPIService(itemService, cfg), cfg.OpenAPI, ginEngine.BasePath())
+		setupOpenAPIRoutes(ginEngine, limitedDataGroup, openAPIHandler, cfg.OpenAPI)
+	}
+
+	if cfg.GRPC == nil {
+		return nil, nil
+	}
+	grpcServer := grpcserver.NewServer(cfg, itemService, metadataService, authenticator, tenantResolver, rateLimiter)
+	if err := serveGRPC(grpcServer, cfg.GRPC.GetAddress(), cfg.GRPC.TLS != nil); err != nil {
+		return nil, err
+	}
+	return grpcServer, nil
 }
 
 func setupItemRoutes(engine *gin.RouterGroup, itemHandler handler.ItemHandler) {
 	itemsGroup := engine.Group("/items")
 	{
 		itemsGroup.POST("/:table_name", itemHandler.CreateItem)
+		itemsGroup.POST("/:table_name/import", itemHandler.ImportItems)
 		itemsGroup.GET("/:table_name", itemHandler.GetItems)
 		itemsGroup.GET("/:table_name/:id", itemHandler.GetItemByID)
+		itemsGroup.GET("/:table_name/:id/history", itemHandler.GetItemHistory)
 		itemsGroup.PUT("/:table_name/:id", itemHandler.UpdateItem)
 		itemsGroup.DELETE("/:table_name/:id", itemHandler.DeleteItem)
 	}
@@ -43,6 +165,74 @@ func setupItemRoutes(engine *gin.RouterGroup, itemHandler handler.ItemHandler) {
 	logrus.Info("item routes configured successfully")
 }
 
+func setupAPIKeyRoutes(engine *gin.RouterGroup, apiKeyHandler handler.APIKeyHandler) {
+	apiKeysGroup := engine.Group("/api-keys")
+	{
+		apiKeysGroup.POST("", apiKeyHandler.CreateKey)
+		apiKeysGroup.GET("", apiKeyHandler.ListKeys)
+		apiKeysGroup.DELETE("/:id", apiKeyHandler.RevokeKey)
+	}
+
+	logrus.Info("api key routes configured successfully")
+}
+
+func setupAuditRoutes(engine *gin.RouterGroup, auditHandler handler.AuditHandler) {
+	engine.GET("/audit/:table_name/:id", auditHandler.GetHistory)
+
+	logrus.Info("audit routes configured successfully")
+}
+
+func setupMetadataRoutes(engine *gin.RouterGroup, metadataHandler handler.MetadataHandler) {
+	engine.GET("/tables", metadataHandler.ListTables)
+	engine.GET("/tables/:table_name", metadataHandler.GetTable)
+	engine.GET("/schema/:table_name", metadataHandler.GetJSONSchema)
+
+	logrus.Info("metadata routes configured successfully")
+}
+
+func setupGraphQLRoutes(engine *gin.RouterGroup, graphQLHandler handler.GraphQLHandler) {
+	engine.POST("/graphql", graphQLHandler.Execute)
+
+	logrus.Info("graphql routes configured successfully")
+}
+
+// serveGRPC listens on address and serves grpcServer in the background.
+func serveGRPC(grpcServer *grpcserver.Server, address string, secure bool) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logrus.Errorf("failed to listen for grpc on %s: %v", address, err)
+		return err
+	}
+	if !secure {
+		logrus.Warnf("grpc server on %s does not use TLS, set GRPCConfig.TLS unless a proxy terminates TLS", listener.Addr())
+	}
+
+	go func() {
+		if err := grpcServer.Serve(listener); err != nil {
+			logrus.Errorf("grpc server stopped: %v", err)
+		}
+	}()
+
+	logrus.Infof("grpc server listening on %s", listener.Addr())
+	return nil
+}
+
+// setupOpenAPIRoutes serves the document next to the data routes. The
+// Swagger UI page holds no data and is served without authentication.
+func setupOpenAPIRoutes(publicGroup, dataGroup *gin.RouterGroup, openAPIHandler handler.OpenAPIHandler, cfg *config.OpenAPIConfig) {
+	dataGroup.GET("/openapi.json", openAPIHandler.GetDocument)
+	if cfg.SwaggerUI {
+		publicGroup.GET("/docs", openAPIHandler.SwaggerUI)
+		if cfg.SwaggerUIAssets != nil {
+			publicGroup.StaticFS(handler.SwaggerUIAssetsPath, http.FS(cfg.SwaggerUIAssets))
+		} else if cfg.SwaggerUIIntegrity.CSS == "" || cfg.SwaggerUIIntegrity.JS == "" {
+			logrus.Warnf("swagger ui loads its assets from %s without integrity hashes", cfg.GetSwaggerUIAssetsURL())
+		}
+	}
+
+	logrus.Info("openapi routes configured successfully")
+}
+
 //func main() {
 //	appEngine := gin.Default()
 //	SetUpAutoGeneratedApis(&config.GenApiConfig{
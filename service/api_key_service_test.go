@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"testing"
+	"time"
+)
+
+func TestValidateCreateRequest(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		req    *domains.CreateAPIKeyRequest
+		fields []string
+	}{
+		{name: "valid", req: &domains.CreateAPIKeyRequest{Name: "reporting", Role: "web_user", AllowedTables: []string{"posts"}, AllowedOperations: []string{"read"}, ExpiresAt: &future}},
+		{name: "missing role", req: &domains.CreateAPIKeyRequest{Name: "reporting"}, fields: []string{"role"}},
+		{name: "blank name and role", req: &domains.CreateAPIKeyRequest{Name: " ", Role: " "}, fields: []string{"name", "role"}},
+		{name: "invalid scope", req: &domains.CreateAPIKeyRequest{Name: "reporting", Role: "web_user", AllowedTables: []string{"posts;"}, AllowedOperations: []string{"truncate"}}, fields: []string{"allowed_tables[0]", "allowed_operations[0]"}},
+		{name: "expired", req: &domains.CreateAPIKeyRequest{Name: "reporting", Role: "web_user", ExpiresAt: &past}, fields: []string{"expires_at"}},
+	}
+
+	s := &APIKeyService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateCreateRequest(tt.req)
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("validateCreateRequest() = %v, want nil", err)
+				}
+				return
+			}
+
+			var validationErr *domains.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("validateCreateRequest() = %v, want a validation error", err)
+			}
+			if len(validationErr.Fields) != len(tt.fields) {
+				t.Fatalf("validateCreateRequest() fields = %v, want %v", validationErr.Fields, tt.fields)
+			}
+			for i, field := range tt.fields {
+				if validationErr.Fields[i].Field != field {
+					t.Errorf("field %d = %q, want %q", i, validationErr.Fields[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckKey(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		key     domains.APIKey
+		wantErr bool
+	}{
+		{name: "valid", key: domains.APIKey{Role: "web_user", ExpiresAt: &future}},
+		{name: "no role", key: domains.APIKey{}, wantErr: true},
+		{name: "revoked", key: domains.APIKey{Role: "web_user", RevokedAt: &past}, wantErr: true},
+		{name: "expired", key: domains.APIKey{Role: "web_user", ExpiresAt: &past}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkKey(&tt.key, now)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("checkKey() = %v, want nil", err)
+				}
+				return
+			}
+			var domainErr *domains.Error
+			if !errors.As(err, &domainErr) || domainErr.Code != domains.ErrCodeUnauthorized {
+				t.Fatalf("checkKey() = %v, want unauthorized", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/abdulaziz-go/go-gen-apis/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditService struct {
+	repo  *repository.AuditRepository
+	items *ItemService
+}
+
+func NewAuditService(repo *repository.AuditRepository, items *ItemService) *AuditService {
+	return &AuditService{repo: repo, items: items}
+}
+
+// GetHistory returns the audit trail of a row. It is available to everyone
+// allowed to read the table. The audit table is read without the request
+// session, so under row-level security the trail is only returned for rows
+// the principal can read.
+func (s *AuditService) GetHistory(ctx context.Context, tableName, idStr string) ([]domains.AuditEntry, error) {
+	if err := s.items.checkTableAccess(ctx, tableName, config.OperationRead); err != nil {
+		return nil, err
+	}
+
+	id, err := s.items.convertAndValidateID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.items.cfg.RowLevelSecurity != nil {
+		if _, err := s.items.repo.GetByID(ctx, tableName, id, nil); err != nil {
+			logrus.Errorf("service: failed to check audited row of table %s: %v", tableName, err)
+			return nil, fmt.Errorf("failed to get audit history: %w", err)
+		}
+	}
+
+	scope, err := s.items.repo.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.GetHistory(ctx, tableName, fmt.Sprint(id), scope)
+	if err != nil {
+		logrus.Errorf("service: failed to get audit history for table %s: %v", tableName, err)
+		return nil, fmt.Errorf("failed to get audit history: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The patterns below match the deparsed form PostgreSQL reports for simple
+// single column CHECK constraints, such as "(price > (0)::numeric)",
+// "(char_length((name)::text) <= 50)" or "(status = ANY (ARRAY['a'::text]))".
+const (
+	checkColumnPattern = `\(*("(?:[^"]|"")+"|[a-zA-Z_][a-zA-Z0-9_$]*)\)*(?:::[a-z][a-z ]*(?:\[\])?)?`
+	checkNumberPattern = `\(*'?(-?\d+(?:\.\d+)?)'?\)*(?:::[a-z][a-z ]*)?`
+)
+
+var (
+	checkComparison = regexp.MustCompile(`^` + checkColumnPattern + ` (>=|<=|>|<) ` + checkNumberPattern + `$`)
+	checkLength     = regexp.MustCompile(`^(?:char_length|character_length|length)\(` + checkColumnPattern + `\) (>=|<=|>|<) ` + checkNumberPattern + `$`)
+	checkAny        = regexp.MustCompile(`^` + checkColumnPattern + ` = ANY \(\(?ARRAY\[(.*?)\]\)?(?:::[a-z][a-z ]*\[\])?\)$`)
+	checkRegex      = regexp.MustCompile(`^` + checkColumnPattern + ` ~ '((?:[^']|'')*)'::text$`)
+	checkNotEmpty   = regexp.MustCompile(`^` + checkColumnPattern + ` <> ''::(?:text|character varying)$`)
+	checkLiteral    = regexp.MustCompile(`^'((?:[^']|'')*)'::[a-z][a-z ]*$`)
+)
+
+// translateCheckConstraint turns a single column CHECK constraint into JSON
+// Schema keywords for that column. It reports false when any part of the
+// expression cannot be expressed in JSON Schema.
+func translateCheckConstraint(check domains.CheckConstraint) (map[string]any, bool) {
+	if len(check.Columns) != 1 {
+		return nil, false
+	}
+	column := check.Columns[0]
+
+	keywords := map[string]any{}
+	for _, conjunct := range splitConjuncts(check.Expression) {
+		if !translateCheckConjunct(conjunct, column, keywords) {
+			return nil, false
+		}
+	}
+	return keywords, len(keywords) > 0
+}
+
+func translateCheckConjunct(expression, column string, keywords map[string]any) bool {
+	if match := checkComparison.FindStringSubmatch(expression); match != nil {
+		if unquoteCheckIdentifier(match[1]) != column {
+			return false
+		}
+		number, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			return false
+		}
+		keywords[map[string]string{">=": "minimum", ">": "exclusiveMinimum", "<=": "maximum", "<": "exclusiveMaximum"}[match[2]]] = number
+		return true
+	}
+
+	if match := checkLength.FindStringSubmatch(expression); match != nil {
+		if unquoteCheckIdentifier(match[1]) != column {
+			return false
+		}
+		length, err := strconv.Atoi(match[3])
+		if err != nil {
+			return false
+		}
+		switch match[2] {
+		case ">=":
+			keywords["minLength"] = length
+		case ">":
+			keywords["minLength"] = length + 1
+		case "<=":
+			keywords["maxLength"] = length
+		case "<":
+			keywords["maxLength"] = length - 1
+		}
+		return true
+	}
+
+	if match := checkAny.FindStringSubmatch(expression); match != nil {
+		if unquoteCheckIdentifier(match[1]) != column {
+			return false
+		}
+		var values []any
+		for _, element := range splitTopLevel(match[2], ", ") {
+			literal := checkLiteral.FindStringSubmatch(strings.TrimSpace(element))
+			if literal == nil {
+				return false
+			}
+			values = append(values, strings.ReplaceAll(literal[1], "''", "'"))
+		}
+		keywords["enum"] = values
+		return true
+	}
+
+	if match := checkRegex.FindStringSubmatch(expression); match != nil {
+		if unquoteCheckIdentifier(match[1]) != column {
+			return false
+		}
+		keywords["pattern"] = strings.ReplaceAll(match[2], "''", "'")
+		return true
+	}
+
+	if match := checkNotEmpty.FindStringSubmatch(expression); match != nil {
+		if unquoteCheckIdentifier(match[1]) != column {
+			return false
+		}
+		keywords["minLength"] = 1
+		return true
+	}
+
+	return false
+}
+
+// splitConjuncts splits an expression on its top level AND operators and
+// strips the parentheses around each part.
+func splitConjuncts(expression string) []string {
+	parts := splitTopLevel(stripParentheses(expression), " AND ")
+	for i, part := range parts {
+		parts[i] = stripParentheses(part)
+	}
+	return parts
+}
+
+// splitTopLevel splits s on sep outside of parentheses, brackets and quotes.
+func splitTopLevel(s, sep string) []string {
+	var parts []string
+	depth, start := 0, 0
+	inQuotes := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// stripParentheses removes parentheses enclosing the whole expression.
+func stripParentheses(expression string) string {
+	expression = strings.TrimSpace(expression)
+	for strings.HasPrefix(expression, "(") && strings.HasSuffix(expression, ")") {
+		depth := 0
+		enclosing := true
+		for i := 0; i < len(expression)-1; i++ {
+			switch expression[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				enclosing = false
+				break
+			}
+		}
+		if !enclosing {
+			break
+		}
+		expression = strings.TrimSpace(expression[1 : len(expression)-1])
+	}
+	return expression
+}
+
+func unquoteCheckIdentifier(identifier string) string {
+	if strings.HasPrefix(identifier, `"`) && strings.HasSuffix(identifier, `"`) {
+		return strings.ReplaceAll(identifier[1:len(identifier)-1], `""`, `"`)
+	}
+	return identifier
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"reflect"
+	"testing"
+)
+
+func TestTranslateCheckConstraint(t *testing.T) {
+	tests := []struct {
+		name       string
+		column     string
+		expression string
+		want       map[string]any
+	}{
+		{name: "minimum", column: "price", expression: "(price >= (0)::numeric)", want: map[string]any{"minimum": 0.0}},
+		{name: "exclusive minimum", column: "price", expression: "(price > (0)::numeric)", want: map[string]any{"exclusiveMinimum": 0.0}},
+		{name: "negative decimal maximum", column: "delta", expression: "(delta <= '-1.5'::numeric)", want: map[string]any{"maximum": -1.5}},
+		{name: "integer exclusive maximum", column: "age", expression: "(age < 150)", want: map[string]any{"exclusiveMaximum": 150.0}},
+		{name: "range", column: "age", expression: "((age >= 0) AND (age <= 150))", want: map[string]any{"minimum": 0.0, "maximum": 150.0}},
+		{name: "max length", column: "name", expression: "(char_length((name)::text) <= 50)", want: map[string]any{"maxLength": 50}},
+		{name: "exclusive min length", column: "name", expression: "(length(name) > 2)", want: map[string]any{"minLength": 3}},
+		{name: "exclusive max length", column: "code", expression: "(character_length((code)::text) < 10)", want: map[string]any{"maxLength": 9}},
+		{
+			name:       "enum",
+			column:     "status",
+			expression: "((status)::text = ANY ((ARRAY['active'::character varying, 'it''s'::character varying])::text[]))",
+			want:       map[string]any{"enum": []any{"active", "it's"}},
+		},
+		{name: "enum of text", column: "status", expression: "(status = ANY (ARRAY['a'::text, 'b'::text]))", want: map[string]any{"enum": []any{"a", "b"}}},
+		{name: "enum with separator in value", column: "status", expression: "(status = ANY (ARRAY['a, b'::text]))", want: map[string]any{"enum": []any{"a, b"}}},
+		{name: "pattern", column: "email", expression: "((email)::text ~ '^[^@]+@[^@]+$'::text)", want: map[string]any{"pattern": "^[^@]+@[^@]+$"}},
+		{name: "pattern with quote", column: "name", expression: "(name ~ '^[a-z'']+$'::text)", want: map[string]any{"pattern": "^[a-z']+$"}},
+		{name: "not empty", column: "name", expression: "((name)::text <> ''::text)", want: map[string]any{"minLength": 1}},
+		{name: "quoted column", column: "Unit Price", expression: `("Unit Price" > (0)::numeric)`, want: map[string]any{"exclusiveMinimum": 0.0}},
+		{name: "other column", column: "price", expression: "(cost > (0)::numeric)"},
+		{name: "comparison with a column", column: "end_at", expression: "(end_at > start_at)"},
+		{name: "partly translatable", column: "age", expression: "((age >= 0) AND ((age % 2) = 0))"},
+		{name: "disjunction", column: "age", expression: "((age < 0) OR (age > 10))"},
+		{name: "function call", column: "name", expression: "(lower(name) = name)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := domains.CheckConstraint{Name: "check", Columns: []string{tt.column}, Expression: tt.expression}
+			got, ok := translateCheckConstraint(check)
+			if ok != (tt.want != nil) {
+				t.Fatalf("translateCheckConstraint(%s) = %v, %v, want %v", tt.expression, got, ok, tt.want)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("translateCheckConstraint(%s) = %#v, want %#v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateCheckConstraintColumns(t *testing.T) {
+	check := domains.CheckConstraint{Name: "check", Columns: []string{"start_at", "end_at"}, Expression: "(end_at > start_at)"}
+	if got, ok := translateCheckConstraint(check); ok {
+		t.Errorf("translateCheckConstraint() = %v, want multi column constraints left untranslated", got)
+	}
+}
+
+func TestSplitTopLevel(t *testing.T) {
+	tests := []struct {
+		input string
+		sep   string
+		want  []string
+	}{
+		{input: "a AND b", sep: " AND ", want: []string{"a", "b"}},
+		{input: "(a AND b) AND c", sep: " AND ", want: []string{"(a AND b)", "c"}},
+		{input: "'x AND y' AND c", sep: " AND ", want: []string{"'x AND y'", "c"}},
+		{input: "'a', ARRAY['b', 'c']", sep: ", ", want: []string{"'a'", "ARRAY['b', 'c']"}},
+		{input: "a", sep: ", ", want: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := splitTopLevel(tt.input, tt.sep); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTopLevel(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripParentheses(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "((a > 0))", want: "a > 0"},
+		{input: "(a > 0) AND (b > 0)", want: "(a > 0) AND (b > 0)"},
+		{input: " ( a ) ", want: "a"},
+		{input: "a", want: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := stripParentheses(tt.input); got != tt.want {
+				t.Errorf("stripParentheses(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
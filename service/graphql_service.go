@@ -0,0 +1,761 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/sirupsen/logrus"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var graphQLNamePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// graphQLReservedNames are the type names the generated schema always uses.
+var graphQLReservedNames = []string{"Query", "Mutation", "SortDirection", "JSON", "BigInt", "String", "Int", "Float", "Boolean", "ID"}
+
+var graphQLSortDirection = graphql.NewEnum(graphql.EnumConfig{
+	Name: "SortDirection",
+	Values: graphql.EnumValueConfigMap{
+		domains.SORT_ASC:  &graphql.EnumValueConfig{Value: domains.SORT_ASC},
+		domains.SORT_DESC: &graphql.EnumValueConfig{Value: domains.SORT_DESC},
+	},
+})
+
+// graphQLJSON carries json and jsonb values unchanged.
+var graphQLJSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:         "JSON",
+	Description:  "Any JSON value.",
+	Serialize:    func(value any) any { return value },
+	ParseValue:   func(value any) any { return value },
+	ParseLiteral: graphQLLiteralValue,
+})
+
+// graphQLBigInt carries bigint values, they do not fit the 32 bit Int.
+var graphQLBigInt = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "BigInt",
+	Description: "A 64 bit integer, given as a number or a string.",
+	Serialize:   func(value any) any { return value },
+	ParseValue: func(value any) any {
+		switch v := value.(type) {
+		case int:
+			return int64(v)
+		case int64, string:
+			return v
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v)
+			}
+		}
+		return nil
+	},
+	ParseLiteral: func(value ast.Value) any {
+		switch v := value.(type) {
+		case *ast.IntValue:
+			if number, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+				return number
+			}
+		case *ast.StringValue:
+			return v.Value
+		}
+		return nil
+	},
+})
+
+type GraphQLService struct {
+	items *ItemService
+	cfg   *config.GenApiConfig
+
+	schemaMu sync.Mutex
+	schemas  map[string]cachedGraphQLSchema
+}
+
+type cachedGraphQLSchema struct {
+	schema    *graphql.Schema
+	expiresAt time.Time
+}
+
+func NewGraphQLService(items *ItemService, cfg *config.GenApiConfig) *GraphQLService {
+	return &GraphQLService{items: items, cfg: cfg, schemas: make(map[string]cachedGraphQLSchema)}
+}
+
+// graphQLTable holds the generated types of a table.
+type graphQLTable struct {
+	name        string
+	schema      *domains.TableInfo
+	writable    *domains.TableInfo
+	object      *graphql.Object
+	list        *graphql.Object
+	filter      *graphql.InputObject
+	createInput *graphql.InputObject
+	updateInput *graphql.InputObject
+}
+
+// Execute runs a GraphQL request against the schema of the tables the caller
+// can reach. Errors of the request itself are reported in the result, the
+// returned error is reserved for failures to build the schema.
+func (s *GraphQLService) Execute(ctx context.Context, req *domains.GraphQLRequest) (*graphql.Result, error) {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, nil
+	}
+
+	depth, fields := selectionSize(document)
+	if maxDepth := s.cfg.GraphQL.GetMaxDepth(); depth > maxDepth {
+		message := fmt.Sprintf("query depth %d exceeds the maximum of %d", depth, maxDepth)
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(message)}}, nil
+	}
+	if maxFields := s.cfg.GraphQL.GetMaxFields(); fields > maxFields {
+		message := fmt.Sprintf("query selects %d fields, more than the maximum of %d", fields, maxFields)
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(message)}}, nil
+	}
+
+	schema, err := s.cachedSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	validation := graphql.ValidateDocument(schema, document, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}, nil
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        *schema,
+		AST:           document,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	}), nil
+}
+
+// cachedSchema returns the schema of the caller, which is built once per
+// schema cache TTL for every combination of what it depends on.
+func (s *GraphQLService) cachedSchema(ctx context.Context) (*graphql.Schema, error) {
+	key := graphQLSchemaKey(ctx)
+	now := time.Now()
+
+	s.schemaMu.Lock()
+	cached, ok := s.schemas[key]
+	s.schemaMu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.schema, nil
+	}
+
+	schema, err := s.schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.schemaMu.Lock()
+	defer s.schemaMu.Unlock()
+	for cachedKey, cached := range s.schemas {
+		if !now.Before(cached.expiresAt) {
+			delete(s.schemas, cachedKey)
+		}
+	}
+	s.schemas[key] = cachedGraphQLSchema{schema: schema, expiresAt: now.Add(s.cfg.GetSchemaCacheTTL())}
+	return schema, nil
+}
+
+// graphQLSchemaKey identifies what the schema of a caller depends on: the
+// role and scopes of its principal, its tenant and the database schema it is
+// routed to.
+func graphQLSchemaKey(ctx context.Context) string {
+	var key struct {
+		Role       string
+		Tables     []string
+		Operations []string
+		Tenant     string
+		Schema     string
+	}
+	if principal := domains.PrincipalFromContext(ctx); principal != nil {
+		key.Role = principal.Role
+		key.Tables = principal.AllowedTables
+		key.Operations = principal.AllowedOperations
+	}
+	key.Tenant = domains.TenantFromContext(ctx)
+	key.Schema = domains.SchemaFromContext(ctx)
+
+	encoded, _ := json.Marshal(key)
+	return string(encoded)
+}
+
+// schema builds the GraphQL schema of the tables the caller can reach. Every
+// table gets an object type, list and by-id queries and create, update and
+// delete mutations, limited to the operations allowed on it.
+func (s *GraphQLService) schema(ctx context.Context) (*graphql.Schema, error) {
+	tableNames, err := s.items.repo.ListTables(ctx)
+	if err != nil {
+		logrus.Errorf("service: failed to list tables for graphql schema: %v", err)
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	reserved := map[string]bool{}
+	for _, name := range graphQLReservedNames {
+		reserved[name] = true
+	}
+
+	tables := map[string]*graphQLTable{}
+	var order []*graphQLTable
+	for _, tableName := range tableNames {
+		if !s.items.isTableListed(ctx, tableName) || strings.HasPrefix(tableName, "__") {
+			continue
+		}
+
+		typeNames := []string{tableName, tableName + "_list", tableName + "_filter", tableName + "_create_input", tableName + "_update_input"}
+		if slices.ContainsFunc(typeNames, func(name string) bool { return reserved[name] }) {
+			logrus.Warnf("service: skipping table %s in graphql schema: its type names are taken", tableName)
+			continue
+		}
+
+		schema, err := s.items.repo.GetTableSchema(ctx, tableName)
+		if err != nil {
+			logrus.Warnf("service: skipping table %s in graphql schema: %v", tableName, err)
+			continue
+		}
+		scope, err := s.items.repo.TenantScope(ctx, tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range typeNames {
+			reserved[name] = true
+		}
+		visible := graphQLColumns(s.items.visibleSchema(ctx, tableName, schema))
+		table := &graphQLTable{name: tableName, schema: visible, writable: withoutTenantColumn(visible, scope)}
+		tables[tableName] = table
+		order = append(order, table)
+	}
+
+	for _, table := range order {
+		s.buildTypes(ctx, table, tables, order)
+	}
+
+	query := graphql.Fields{}
+	mutation := graphql.Fields{}
+	for _, table := range order {
+		s.addQueryFields(ctx, query, table)
+		s.addMutationFields(ctx, mutation, table)
+	}
+	if len(query) == 0 {
+		return nil, domains.NewError(domains.ErrCodeNotFound, "no tables are available")
+	}
+
+	schemaConfig := graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: query}),
+	}
+	if len(mutation) > 0 {
+		schemaConfig.Mutation = graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: mutation})
+	}
+
+	schema, err := graphql.NewSchema(schemaConfig)
+	if err != nil {
+		logrus.Errorf("service: failed to build graphql schema: %v", err)
+		return nil, fmt.Errorf("failed to build graphql schema: %w", err)
+	}
+	return &schema, nil
+}
+
+func (s *GraphQLService) allowed(ctx context.Context, tableName, operation string) bool {
+	return s.cfg.IsOperationAllowed(tableName, operation) && domains.PrincipalFromContext(ctx).CanPerform(operation)
+}
+
+func (s *GraphQLService) buildTypes(ctx context.Context, table *graphQLTable, tables map[string]*graphQLTable, order []*graphQLTable) {
+	table.object = graphql.NewObject(graphql.ObjectConfig{
+		Name:        table.name,
+		Description: stringOrEmpty(table.schema.Comment),
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return s.objectFields(ctx, table, tables, order)
+		}),
+	})
+
+	table.list = graphql.NewObject(graphql.ObjectConfig{
+		Name: table.name + "_list",
+		Fields: graphql.Fields{
+			"data":   &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(table.object)))},
+			"total":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"limit":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"offset": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	filterFields := graphql.InputObjectConfigFieldMap{}
+	createFields := graphql.InputObjectConfigFieldMap{}
+	updateFields := graphql.InputObjectConfigFieldMap{}
+	for _, column := range table.writable.Columns {
+		columnType := graphQLType(column, column.DataType)
+
+		if column.DataType != "json" && column.DataType != "jsonb" && !strings.HasSuffix(column.DataType, "[]") {
+			filterFields[column.Name] = &graphql.InputObjectFieldConfig{
+				Type:        graphql.NewList(graphql.NewNonNull(columnType)),
+				Description: "Matches rows whose value is one of the given values.",
+			}
+		}
+
+		if column.Name == table.writable.PrimaryKey || column.IsGenerated {
+			continue
+		}
+		createType := graphql.Input(columnType)
+		if !column.IsNullable && !column.HasDefault {
+			createType = graphql.NewNonNull(columnType)
+		}
+		createFields[column.Name] = &graphql.InputObjectFieldConfig{Type: createType, Description: stringOrEmpty(column.Comment)}
+		updateFields[column.Name] = &graphql.InputObjectFieldConfig{Type: columnType, Description: stringOrEmpty(column.Comment)}
+	}
+
+	if len(filterFields) > 0 {
+		table.filter = graphql.NewInputObject(graphql.InputObjectConfig{Name: table.name + "_filter", Fields: filterFields})
+	}
+	if len(createFields) > 0 {
+		table.createInput = graphql.NewInputObject(graphql.InputObjectConfig{Name: table.name + "_create_input", Fields: createFields})
+		table.updateInput = graphql.NewInputObject(graphql.InputObjectConfig{Name: table.name + "_update_input", Fields: updateFields})
+	}
+}
+
+// objectFields lists the columns of a table and its relationships. A single
+// column foreign key adds a field to the referenced row, named after the
+// column without its "_id" suffix, and a field listing the referencing rows
+// to the referenced table, named "<table>_by_<column>". Relationships query
+// the database for every row they are resolved for, so each resolution takes a
+// rate limit token like a root field.
+func (s *GraphQLService) objectFields(ctx context.Context, table *graphQLTable, tables map[string]*graphQLTable, order []*graphQLTable) graphql.Fields {
+	fields := graphql.Fields{}
+	for _, column := range table.schema.Columns {
+		columnType := graphQLType(column, column.DataType)
+		if !column.IsNullable {
+			columnType = graphql.NewNonNull(columnType)
+		}
+		fields[column.Name] = &graphql.Field{Type: columnType, Description: stringOrEmpty(column.Comment)}
+		if strings.TrimSuffix(column.DataType, "[]") == "numeric" {
+			fields[column.Name].Resolve = resolveNumeric
+		}
+	}
+
+	addField := func(name string, field *graphql.Field) {
+		if _, taken := fields[name]; taken || !graphQLNamePattern.MatchString(name) {
+			logrus.Warnf("service: skipping relationship %s of table %s in graphql schema", name, table.name)
+			return
+		}
+		fields[name] = field
+	}
+
+	for _, foreignKey := range table.schema.ForeignKeys {
+		referenced, ok := tables[foreignKey.ReferencedTable]
+		if !ok || len(foreignKey.Columns) != 1 || !hasColumn(referenced.schema, foreignKey.ReferencedColumns[0]) || !s.allowed(ctx, referenced.name, config.OperationRead) {
+			continue
+		}
+
+		name := foreignKey.Name
+		if trimmed, found := strings.CutSuffix(foreignKey.Columns[0], "_id"); found && trimmed != "" {
+			name = trimmed
+		}
+		addField(name, &graphql.Field{
+			Type:    referenced.object,
+			Resolve: limited(referenced.name, config.OperationRead, s.resolveReferenced(referenced, foreignKey.Columns[0], foreignKey.ReferencedColumns[0])),
+		})
+	}
+
+	for _, referencing := range order {
+		if !s.allowed(ctx, referencing.name, config.OperationRead) {
+			continue
+		}
+		for _, foreignKey := range referencing.schema.ForeignKeys {
+			if foreignKey.ReferencedTable != table.name || len(foreignKey.Columns) != 1 || !hasColumn(table.schema, foreignKey.ReferencedColumns[0]) {
+				continue
+			}
+			addField(referencing.name+"_by_"+foreignKey.Columns[0], &graphql.Field{
+				Type:    graphql.NewNonNull(referencing.list),
+				Args:    listArguments(referencing),
+				Resolve: limited(referencing.name, config.OperationRead, s.resolveReferencing(referencing, foreignKey.Columns[0], foreignKey.ReferencedColumns[0])),
+			})
+		}
+	}
+	return fields
+}
+
+func (s *GraphQLService) addQueryFields(ctx context.Context, fields graphql.Fields, table *graphQLTable) {
+	if !s.allowed(ctx, table.name, config.OperationRead) {
+		return
+	}
+
+	addRootField(fields, table.name, &graphql.Field{
+		Type:        graphql.NewNonNull(table.list),
+		Description: fmt.Sprintf("Lists rows of %s.", table.name),
+		Args:        listArguments(table),
+		Resolve: limited(table.name, config.OperationRead, func(p graphql.ResolveParams) (any, error) {
+			filter := itemFilterFromArgs(p.Args)
+			items, total, err := s.items.GetItems(p.Context, table.name, filter)
+			if err != nil {
+				return nil, err
+			}
+			return listResult(items, total, filter), nil
+		}),
+	})
+
+	if table.schema.PrimaryKey == "" {
+		return
+	}
+	addRootField(fields, table.name+"_by_id", &graphql.Field{
+		Type:        table.object,
+		Description: fmt.Sprintf("Gets a row of %s by its primary key.", table.name),
+		Args:        graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+		Resolve: limited(table.name, config.OperationRead, func(p graphql.ResolveParams) (any, error) {
+			item, err := s.items.GetSingleItem(p.Context, table.name, p.Args["id"].(string), nil)
+			if err != nil {
+				return nil, err
+			}
+			return item, nil
+		}),
+	})
+}
+
+func (s *GraphQLService) addMutationFields(ctx context.Context, fields graphql.Fields, table *graphQLTable) {
+	idArgument := &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}
+
+	if table.createInput != nil && s.allowed(ctx, table.name, config.OperationCreate) {
+		addRootField(fields, "create_"+table.name, &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(table.object))),
+			Description: fmt.Sprintf("Creates rows in %s.", table.name),
+			Args: graphql.FieldConfigArgument{
+				"data": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(table.createInput)))},
+			},
+			Resolve: limited(table.name, config.OperationCreate, func(p graphql.ResolveParams) (any, error) {
+				var rows []map[string]any
+				for _, row := range p.Args["data"].([]any) {
+					rows = append(rows, row.(map[string]any))
+				}
+				return s.items.CreateItem(p.Context, table.name, &domains.CreateItemRequest{Data: rows})
+			}),
+		})
+	}
+
+	if table.schema.PrimaryKey == "" {
+		return
+	}
+
+	if table.updateInput != nil && s.allowed(ctx, table.name, config.OperationUpdate) {
+		addRootField(fields, "update_"+table.name, &graphql.Field{
+			Type:        table.object,
+			Description: fmt.Sprintf("Updates a row of %s by its primary key.", table.name),
+			Args: graphql.FieldConfigArgument{
+				"id":   idArgument,
+				"data": &graphql.ArgumentConfig{Type: graphql.NewNonNull(table.updateInput)},
+			},
+			Resolve: limited(table.name, config.OperationUpdate, func(p graphql.ResolveParams) (any, error) {
+				data, _ := p.Args["data"].(map[string]any)
+				item, err := s.items.UpdateItem(p.Context, table.name, p.Args["id"].(string), &domains.UpdateItemRequest{Data: data})
+				if err != nil {
+					return nil, err
+				}
+				return item, nil
+			}),
+		})
+	}
+
+	if s.allowed(ctx, table.name, config.OperationDelete) {
+		addRootField(fields, "delete_"+table.name, &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Boolean),
+			Description: fmt.Sprintf("Deletes a row of %s by its primary key.", table.name),
+			Args:        graphql.FieldConfigArgument{"id": idArgument},
+			Resolve: limited(table.name, config.OperationDelete, func(p graphql.ResolveParams) (any, error) {
+				if err := s.items.DeleteItem(p.Context, table.name, p.Args["id"].(string)); err != nil {
+					return nil, err
+				}
+				return true, nil
+			}),
+		})
+	}
+}
+
+// limited takes a rate limit token for operation on tableName before resolve
+// runs, so that a request is charged for every root field it selects and for
+// every relationship it resolves.
+func limited(tableName, operation string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		if limiter := domains.RateLimiterFromContext(p.Context); limiter != nil {
+			if err := limiter.Take(p.Context, tableName, operation); err != nil {
+				return nil, err
+			}
+		}
+		return resolve(p)
+	}
+}
+
+// addRootField adds a query or mutation field unless a table with a
+// conflicting name took it first.
+func addRootField(fields graphql.Fields, name string, field *graphql.Field) {
+	if _, taken := fields[name]; taken {
+		logrus.Warnf("service: skipping graphql field %s: the name is taken", name)
+		return
+	}
+	fields[name] = field
+}
+
+// resolveReferenced resolves the row a foreign key column points to. Rows
+// the caller cannot see resolve to null.
+func (s *GraphQLService) resolveReferenced(referenced *graphQLTable, column, referencedColumn string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		row, _ := p.Source.(map[string]any)
+		value := row[column]
+		if value == nil {
+			return nil, nil
+		}
+
+		if referencedColumn == referenced.schema.PrimaryKey {
+			item, err := s.items.GetSingleItem(p.Context, referenced.name, fmt.Sprint(value), nil)
+			var appErr *domains.Error
+			if errors.As(err, &appErr) && appErr.Code == domains.ErrCodeNotFound {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return item, nil
+		}
+
+		filter := &domains.ItemFilter{Limit: 1, Filters: map[string]any{referencedColumn: value}}
+		items, _, err := s.items.GetItems(p.Context, referenced.name, filter)
+		if err != nil || len(items) == 0 {
+			return nil, err
+		}
+		return items[0], nil
+	}
+}
+
+// resolveReferencing lists the rows whose foreign key column points to the
+// source row.
+func (s *GraphQLService) resolveReferencing(referencing *graphQLTable, column, referencedColumn string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		row, _ := p.Source.(map[string]any)
+		filter := itemFilterFromArgs(p.Args)
+		filter.Filters[column] = row[referencedColumn]
+
+		items, total, err := s.items.GetItems(p.Context, referencing.name, filter)
+		if err != nil {
+			return nil, err
+		}
+		return listResult(items, total, filter), nil
+	}
+}
+
+// listArguments mirror the fields of domains.ItemFilter.
+func listArguments(table *graphQLTable) graphql.FieldConfigArgument {
+	arguments := graphql.FieldConfigArgument{
+		"limit":    &graphql.ArgumentConfig{Type: graphql.Int},
+		"offset":   &graphql.ArgumentConfig{Type: graphql.Int},
+		"order_by": &graphql.ArgumentConfig{Type: graphql.String},
+		"sort":     &graphql.ArgumentConfig{Type: graphQLSortDirection},
+		"search":   &graphql.ArgumentConfig{Type: graphql.String},
+	}
+	if table.filter != nil {
+		arguments["where"] = &graphql.ArgumentConfig{Type: table.filter}
+	}
+	return arguments
+}
+
+func itemFilterFromArgs(args map[string]any) *domains.ItemFilter {
+	filter := &domains.ItemFilter{Filters: map[string]any{}}
+	filter.Limit, _ = args["limit"].(int)
+	filter.Offset, _ = args["offset"].(int)
+	filter.OrderBy, _ = args["order_by"].(string)
+	filter.Sort, _ = args["sort"].(string)
+	filter.Search, _ = args["search"].(string)
+
+	where, _ := args["where"].(map[string]any)
+	for column, value := range where {
+		switch values, _ := value.([]any); len(values) {
+		case 0:
+		case 1:
+			filter.Filters[column] = values[0]
+		default:
+			filter.Filters[column] = values
+		}
+	}
+	return filter
+}
+
+func listResult(items []map[string]any, total int, filter *domains.ItemFilter) map[string]any {
+	if items == nil {
+		items = []map[string]any{}
+	}
+	return map[string]any{
+		"data":   items,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	}
+}
+
+// graphQLColumns returns a copy of schema without the columns whose names are
+// not valid GraphQL names.
+func graphQLColumns(schema *domains.TableInfo) *domains.TableInfo {
+	valid := *schema
+	valid.Columns = nil
+	for _, column := range schema.Columns {
+		if graphQLNamePattern.MatchString(column.Name) && !strings.HasPrefix(column.Name, "__") {
+			valid.Columns = append(valid.Columns, column)
+		}
+	}
+	return &valid
+}
+
+// graphQLType maps a column type to a GraphQL type the same way the
+// validator checks values.
+func graphQLType(column domains.DatabaseColumn, dataType string) graphql.Output {
+	if strings.HasSuffix(dataType, "[]") {
+		return graphql.NewList(graphQLType(column, strings.TrimSuffix(dataType, "[]")))
+	}
+
+	switch dataType {
+	case "smallint", "int2", "integer", "int4":
+		return graphql.Int
+	case "bigint", "int8":
+		return graphQLBigInt
+	case "numeric", "decimal", "real", "double precision", "float4", "float8":
+		return graphql.Float
+	case "boolean", "bool":
+		return graphql.Boolean
+	case "json", "jsonb":
+		return graphQLJSON
+	default:
+		return graphql.String
+	}
+}
+
+// resolveNumeric reads a numeric column. Rows hold numeric values as exact
+// json.Number values, which the Float scalar does not take.
+func resolveNumeric(p graphql.ResolveParams) (any, error) {
+	row, _ := p.Source.(map[string]any)
+	return floatNumbers(row[p.Info.FieldName]), nil
+}
+
+func floatNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		number, err := v.Float64()
+		if err != nil {
+			return nil
+		}
+		return number
+	case []any:
+		numbers := make([]any, len(v))
+		for i, element := range v {
+			numbers[i] = floatNumbers(element)
+		}
+		return numbers
+	}
+	return value
+}
+
+func graphQLLiteralValue(value ast.Value) any {
+	switch v := value.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.IntValue:
+		if number, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			return number
+		}
+		number, _ := strconv.ParseFloat(v.Value, 64)
+		return number
+	case *ast.FloatValue:
+		number, _ := strconv.ParseFloat(v.Value, 64)
+		return number
+	case *ast.ListValue:
+		values := make([]any, 0, len(v.Values))
+		for _, element := range v.Values {
+			values = append(values, graphQLLiteralValue(element))
+		}
+		return values
+	case *ast.ObjectValue:
+		object := make(map[string]any, len(v.Fields))
+		for _, field := range v.Fields {
+			object[field.Name.Value] = graphQLLiteralValue(field.Value)
+		}
+		return object
+	}
+	return nil
+}
+
+// selectionSize returns the deepest nesting of fields in the operations of
+// document and the number of fields they select. Fragments count with their
+// selections every time they are spread and aliases count as fields of their
+// own. The count stops growing at math.MaxInt32.
+func selectionSize(document *ast.Document) (depth, fields int) {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	type size struct{ depth, fields int }
+	fragmentSizes := map[string]size{}
+	var sizeOf func(selectionSet *ast.SelectionSet) size
+	sizeOf = func(selectionSet *ast.SelectionSet) size {
+		var total size
+		if selectionSet == nil {
+			return total
+		}
+
+		for _, selection := range selectionSet.Selections {
+			var selected size
+			switch selection := selection.(type) {
+			case *ast.Field:
+				selected = sizeOf(selection.SelectionSet)
+				selected = size{depth: selected.depth + 1, fields: selected.fields + 1}
+			case *ast.InlineFragment:
+				selected = sizeOf(selection.SelectionSet)
+			case *ast.FragmentSpread:
+				name := selection.Name.Value
+				known := false
+				selected, known = fragmentSizes[name]
+				if fragment, ok := fragments[name]; ok && !known {
+					// Cyclic spreads are rejected by validation, they count
+					// as empty while the fragment is being measured.
+					fragmentSizes[name] = size{}
+					selected = sizeOf(fragment.SelectionSet)
+					fragmentSizes[name] = selected
+				}
+			}
+			total.depth = max(total.depth, selected.depth)
+			total.fields = min(total.fields+selected.fields, math.MaxInt32)
+		}
+		return total
+	}
+
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			selected := sizeOf(operation.SelectionSet)
+			depth = max(depth, selected.depth)
+			fields = min(fields+selected.fields, math.MaxInt32)
+		}
+	}
+	return depth, fields
+}
+
+func stringOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
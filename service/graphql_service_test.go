@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"testing"
+)
+
+func TestSelectionSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		depth  int
+		fields int
+	}{
+		{name: "single field", query: `{ users { total } }`, depth: 2, fields: 2},
+		{name: "nested", query: `{ users { items { id orders { id } } } }`, depth: 4, fields: 5},
+		{name: "aliases count", query: `{ a: users { total } b: users { total } }`, depth: 2, fields: 4},
+		{name: "inline fragment", query: `{ users { ... on users_list { total } } }`, depth: 2, fields: 2},
+		{
+			name:   "fragment counts every spread",
+			query:  `{ a: users { ...f } b: users { ...f } } fragment f on users_list { total items { id } }`,
+			depth:  3,
+			fields: 8,
+		},
+		{
+			name:   "fragment in fragment",
+			query:  `{ users { ...outer } } fragment outer on users_list { items { ...inner } } fragment inner on users { id name }`,
+			depth:  3,
+			fields: 4,
+		},
+		{name: "cyclic fragments", query: `{ users { ...a } } fragment a on users_list { ...b } fragment b on users_list { ...a total }`, depth: 2, fields: 2},
+		{name: "unknown fragment", query: `{ users { ...missing } }`, depth: 1, fields: 1},
+		{name: "every operation", query: `query a { users { total } } query b { orders { total } }`, depth: 2, fields: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(tt.query)})})
+			if err != nil {
+				t.Fatalf("Parse() = %v", err)
+			}
+
+			depth, fields := selectionSize(document)
+			if depth != tt.depth || fields != tt.fields {
+				t.Errorf("selectionSize() = %d, %d, want %d, %d", depth, fields, tt.depth, tt.fields)
+			}
+		})
+	}
+}
+
+func TestGraphQLSchemaKey(t *testing.T) {
+	base := domains.ContextWithPrincipal(context.Background(), &domains.Principal{Subject: "1", Role: "web_user"})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		same bool
+	}{
+		{name: "same role and another subject", ctx: domains.ContextWithPrincipal(context.Background(), &domains.Principal{Subject: "2", Role: "web_user"}), same: true},
+		{name: "another role", ctx: domains.ContextWithPrincipal(context.Background(), &domains.Principal{Role: "web_admin"})},
+		{name: "narrower tables", ctx: domains.ContextWithPrincipal(context.Background(), &domains.Principal{Role: "web_user", AllowedTables: []string{"users"}})},
+		{name: "narrower operations", ctx: domains.ContextWithPrincipal(context.Background(), &domains.Principal{Role: "web_user", AllowedOperations: []string{"read"}})},
+		{name: "another tenant", ctx: domains.ContextWithTenant(base, "acme")},
+		{name: "another schema", ctx: domains.ContextWithSchema(base, "tenant_acme")},
+		{name: "no principal", ctx: context.Background()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := graphQLSchemaKey(tt.ctx) == graphQLSchemaKey(base); same != tt.same {
+				t.Errorf("graphQLSchemaKey() same = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+type recordingRateLimiter struct {
+	taken []string
+	err   error
+}
+
+func (l *recordingRateLimiter) Take(_ context.Context, tableName, operation string) error {
+	l.taken = append(l.taken, tableName+":"+operation)
+	return l.err
+}
+
+func (l *recordingRateLimiter) Charge(context.Context, string, string, int) {}
+
+func TestLimited(t *testing.T) {
+	throttled := domains.NewError(domains.ErrCodeRateLimited, "rate limit exceeded")
+
+	tests := []struct {
+		name     string
+		limiter  *recordingRateLimiter
+		resolved bool
+	}{
+		{name: "without a limiter", resolved: true},
+		{name: "token taken", limiter: &recordingRateLimiter{}, resolved: true},
+		{name: "throttled", limiter: &recordingRateLimiter{err: throttled}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.limiter != nil {
+				ctx = domains.ContextWithRateLimiter(ctx, tt.limiter)
+			}
+
+			resolved := false
+			resolve := limited("users", config.OperationRead, func(graphql.ResolveParams) (any, error) {
+				resolved = true
+				return nil, nil
+			})
+			_, err := resolve(graphql.ResolveParams{Context: ctx})
+
+			if resolved != tt.resolved {
+				t.Errorf("resolved = %v, want %v", resolved, tt.resolved)
+			}
+			if !tt.resolved && !errors.Is(err, throttled) {
+				t.Errorf("resolve() = %v, want %v", err, throttled)
+			}
+			if tt.limiter != nil && (len(tt.limiter.taken) != 1 || tt.limiter.taken[0] != "users:read") {
+				t.Errorf("taken = %v, want [users:read]", tt.limiter.taken)
+			}
+		})
+	}
+}
+
+func TestObjectFieldsRelationshipsLimited(t *testing.T) {
+	s := &GraphQLService{cfg: &config.GenApiConfig{}}
+	users := &graphQLTable{name: "users", schema: &domains.TableInfo{Name: "users", PrimaryKey: "id", Columns: []domains.DatabaseColumn{{Name: "id", DataType: "integer"}}}}
+	posts := &graphQLTable{name: "posts", schema: &domains.TableInfo{
+		Name:        "posts",
+		PrimaryKey:  "id",
+		Columns:     []domains.DatabaseColumn{{Name: "id", DataType: "integer"}, {Name: "user_id", DataType: "integer"}},
+		ForeignKeys: []domains.ForeignKey{{Name: "posts_user_id_fkey", Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}}},
+	}}
+	tables := map[string]*graphQLTable{"users": users, "posts": posts}
+	order := []*graphQLTable{users, posts}
+
+	tests := []struct {
+		name  string
+		table *graphQLTable
+		field string
+		want  string
+	}{
+		{name: "referenced row", table: posts, field: "user", want: "users:read"},
+		{name: "referencing rows", table: users, field: "posts_by_user_id", want: "posts:read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, ok := s.objectFields(context.Background(), tt.table, tables, order)[tt.field]
+			if !ok {
+				t.Fatalf("field %s is missing", tt.field)
+			}
+
+			throttled := domains.NewError(domains.ErrCodeRateLimited, "rate limit exceeded")
+			limiter := &recordingRateLimiter{err: throttled}
+			ctx := domains.ContextWithRateLimiter(context.Background(), limiter)
+			source := map[string]any{"id": int64(1), "user_id": int64(1)}
+			if _, err := field.Resolve(graphql.ResolveParams{Context: ctx, Source: source, Args: map[string]any{}}); !errors.Is(err, throttled) {
+				t.Errorf("resolve() = %v, want %v", err, throttled)
+			}
+			if len(limiter.taken) != 1 || limiter.taken[0] != tt.want {
+				t.Errorf("taken = %v, want [%s]", limiter.taken, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/sirupsen/logrus"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxImportErrors stops reading an import once this many problems have been
+// found, the report would not get more useful.
+const maxImportErrors = 100
+
+// ImportItems copies the rows of source into the table in one transaction.
+// Headers are mapped to columns by name, ignoring case, and every row is
+// validated like a create payload. Nothing is written when any row is
+// rejected or when dryRun is set.
+func (s *ItemService) ImportItems(ctx context.Context, tableName string, source domains.ImportSource, dryRun bool) (*domains.ImportResult, error) {
+	if err := s.checkTableAccess(ctx, tableName, config.OperationCreate); err != nil {
+		return nil, err
+	}
+
+	schema, err := s.repo.GetTableSchema(ctx, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %w", err)
+	}
+
+	scope, err := s.repo.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := source.Columns()
+	if err != nil {
+		return nil, domains.NewError(domains.ErrCodeBadRequest, fmt.Sprintf("failed to read header: %v", err))
+	}
+
+	mapping, err := s.mapImportColumns(ctx, tableName, schema, scope, header)
+	if err != nil {
+		return nil, err
+	}
+
+	rowSchema := withoutTenantColumn(schema, scope)
+	var fieldErrors []domains.FieldError
+	row := 0
+	next := func() ([]any, error) {
+		for len(fieldErrors) < maxImportErrors {
+			values, err := source.Next()
+			if err == io.EOF {
+				break
+			}
+			row++
+
+			var invalidRow *domains.InvalidRowError
+			if errors.As(err, &invalidRow) {
+				fieldErrors = append(fieldErrors, domains.FieldError{Field: domains.ImportField(row, ""), Message: invalidRow.Message})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			data, rowValues, rowErrors := mapping.row(row, values, source.Format())
+			rowErrors = append(rowErrors, validateRow(rowSchema, data, false, domains.ImportField(row, "")+".")...)
+			if len(rowErrors) > 0 {
+				fieldErrors = append(fieldErrors, rowErrors...)
+				continue
+			}
+			// Once a row has been rejected nothing will be written, the
+			// remaining rows are only validated.
+			if len(fieldErrors) == 0 {
+				return rowValues, nil
+			}
+		}
+
+		if len(fieldErrors) > 0 {
+			return nil, &domains.ValidationError{Fields: fieldErrors}
+		}
+		return nil, io.EOF
+	}
+
+	imported, err := s.repo.Import(ctx, tableName, mapping.columns, next, dryRun)
+	// The request took a token for its first row, the others are charged
+	// once they are read, whether they were kept or not.
+	if limiter := domains.RateLimiterFromContext(ctx); limiter != nil {
+		limiter.Charge(ctx, tableName, config.OperationCreate, row-1)
+	}
+	if err != nil {
+		logrus.Errorf("service: failed to import items into table %s: %v", tableName, err)
+		return nil, fmt.Errorf("failed to import items: %w", err)
+	}
+
+	return &domains.ImportResult{Rows: imported, DryRun: dryRun}, nil
+}
+
+// importMapping maps the fields of imported records to columns.
+type importMapping struct {
+	// columns are the target columns, read from the record fields at indexes.
+	columns []string
+	types   []string
+	indexes []int
+}
+
+// mapImportColumns matches the header with the table columns and checks once
+// for the whole import what does not depend on the values: unknown,
+// generated, read-only and missing required columns.
+func (s *ItemService) mapImportColumns(ctx context.Context, tableName string, schema *domains.TableInfo, scope *domains.TenantScope, header []string) (*importMapping, error) {
+	mapping := &importMapping{}
+	mapped := map[string]any{}
+	var unknownFields, fieldErrors []domains.FieldError
+
+	for i, name := range header {
+		column, ok := importColumn(schema, name)
+		if !ok || s.cfg.IsStrict(tableName) && s.cfg.IsColumnHidden(tableName, column.Name) {
+			unknownFields = append(unknownFields, domains.FieldError{Field: name, Message: "unknown column"})
+			continue
+		}
+		if _, duplicate := mapped[column.Name]; duplicate {
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: name, Message: "is given more than once"})
+			continue
+		}
+		mapped[column.Name] = nil
+
+		// The primary key is assigned by the database, like on create.
+		if column.Name == schema.PrimaryKey {
+			continue
+		}
+		if column.IsGenerated {
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: column.Name, Message: "is generated by the database and cannot be written"})
+			continue
+		}
+		mapping.columns = append(mapping.columns, column.Name)
+		mapping.types = append(mapping.types, column.DataType)
+		mapping.indexes = append(mapping.indexes, i)
+	}
+
+	if len(unknownFields) > 0 && s.cfg.IsStrict(tableName) {
+		return nil, &domains.UnknownFieldsError{Fields: unknownFields}
+	}
+
+	fieldErrors = append(fieldErrors, tenantFieldErrors(scope, mapped, "")...)
+	fieldErrors = append(fieldErrors, s.checkColumnPolicies(ctx, tableName, mapped, true, "")...)
+	for _, column := range withoutTenantColumn(schema, scope).Columns {
+		if _, ok := mapped[column.Name]; !ok && column.Name != schema.PrimaryKey && !column.IsNullable && !column.HasDefault {
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: column.Name, Message: "is required"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, &domains.ValidationError{Fields: fieldErrors}
+	}
+
+	if len(mapping.columns) == 0 {
+		return nil, domains.NewError(domains.ErrCodeValidationFailed, "no columns to import")
+	}
+	return mapping, nil
+}
+
+// importColumn finds the column named by a header field, preferring an exact
+// match over a case-insensitive one.
+func importColumn(schema *domains.TableInfo, name string) (domains.DatabaseColumn, bool) {
+	for _, column := range schema.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	name = strings.TrimSpace(name)
+	for _, column := range schema.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return domains.DatabaseColumn{}, false
+}
+
+// row picks the mapped fields of a record. Cells of CSV and XLSX files are
+// decoded as JSON for JSON and array columns, as written by the exports, and
+// XLSX date serials are converted for date and time columns.
+func (m *importMapping) row(row int, record []any, format string) (map[string]any, []any, []domains.FieldError) {
+	data := make(map[string]any, len(m.columns))
+	values := make([]any, len(m.columns))
+	var fieldErrors []domains.FieldError
+
+	for i, column := range m.columns {
+		var value any
+		if index := m.indexes[i]; index < len(record) {
+			value = record[index]
+		}
+
+		if text, ok := value.(string); ok && format != domains.ImportFormatNDJSON {
+			dataType := m.types[i]
+			switch {
+			case dataType == "json" || dataType == "jsonb" || strings.HasSuffix(dataType, "[]"):
+				decoded, err := decodeJSONCell(text)
+				if err != nil {
+					fieldErrors = append(fieldErrors, domains.FieldError{Field: domains.ImportField(row, column), Message: "must be valid JSON"})
+					continue
+				}
+				value = decoded
+			case format == domains.ImportFormatXLSX:
+				value = spreadsheetTime(dataType, text)
+			}
+		}
+
+		data[column] = value
+		values[i] = value
+	}
+	return data, values, fieldErrors
+}
+
+// spreadsheetTime formats the date serial of a spreadsheet cell for date and
+// time columns. Other cells are returned unchanged.
+func spreadsheetTime(dataType, text string) any {
+	var layout string
+	switch dataType {
+	case "date":
+		layout = time.DateOnly
+	case "timestamp without time zone", "timestamp with time zone":
+		layout = time.RFC3339Nano
+	case "time without time zone":
+		layout = time.TimeOnly
+	default:
+		return text
+	}
+
+	serial, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return text
+	}
+	parsed, err := excelize.ExcelDateToTime(serial, false)
+	if err != nil {
+		return text
+	}
+	return parsed.Format(layout)
+}
+
+func decodeJSONCell(text string) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
@@ -3,30 +3,76 @@ package service
 import (
 	"context"
 	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
 	"github.com/abdulaziz-go/go-gen-apis/domains"
 	"github.com/abdulaziz-go/go-gen-apis/repository"
 	"github.com/sirupsen/logrus"
+	"math"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
+const (
+	defaultListLimit = 50
+	maxListLimit     = 1000 // for preventing performance problems
+)
+
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 type ItemService struct {
 	repo *repository.ItemRepository
+	cfg  *config.GenApiConfig
 }
 
-func NewItemService(repo *repository.ItemRepository) *ItemService {
-	return &ItemService{repo: repo}
+func NewItemService(repo *repository.ItemRepository, cfg *config.GenApiConfig) *ItemService {
+	return &ItemService{repo: repo, cfg: cfg}
 }
 
 func (s *ItemService) CreateItem(ctx context.Context, tableName string, req *domains.CreateItemRequest) ([]map[string]any, error) {
-	if err := s.validTableName(tableName); err != nil {
+	if err := s.checkTableAccess(ctx, tableName, config.OperationCreate); err != nil {
 		return nil, err
 	}
 
 	if err := s.validateCreateRequest(req); err != nil {
 		return nil, err
 	}
+
+	schema, err := s.repo.GetTableSchema(ctx, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %w", err)
+	}
+
+	scope, err := s.repo.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.IsStrict(tableName) {
+		visible := s.visibleSchema(ctx, tableName, schema)
+		var unknownFields []domains.FieldError
+		for i, data := range req.Data {
+			unknownFields = append(unknownFields, unknownColumns(visible, data, fmt.Sprintf("data[%d].", i))...)
+		}
+		if len(unknownFields) > 0 {
+			return nil, &domains.UnknownFieldsError{Fields: unknownFields}
+		}
+	}
+
+	var fieldErrors []domains.FieldError
+	for i, data := range req.Data {
+		prefix := fmt.Sprintf("data[%d].", i)
+		fieldErrors = append(fieldErrors, tenantFieldErrors(scope, data, prefix)...)
+		fieldErrors = append(fieldErrors, s.checkColumnPolicies(ctx, tableName, data, true, prefix)...)
+		fieldErrors = append(fieldErrors, validateRow(withoutTenantColumn(schema, scope), data, true, prefix)...)
+	}
+	if len(fieldErrors) > 0 {
+		return nil, &domains.ValidationError{Fields: fieldErrors}
+	}
+
 	items, err := s.repo.Create(ctx, tableName, req.Data)
 	if err != nil {
 		logrus.Errorf("service: failed to create items in table %s: %v", tableName, err)
@@ -36,8 +82,12 @@ func (s *ItemService) CreateItem(ctx context.Context, tableName string, req *dom
 	return items, nil
 }
 
-func (s *ItemService) GetSingleItem(ctx context.Context, tableName string, idString string) (map[string]any, error) {
-	if err := s.validTableName(tableName); err != nil {
+func (s *ItemService) GetSingleItem(ctx context.Context, tableName string, idString string, asOf *time.Time) (map[string]any, error) {
+	if err := s.checkTableAccess(ctx, tableName, config.OperationRead); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkVersioned(tableName, asOf != nil); err != nil {
 		return nil, err
 	}
 
@@ -46,7 +96,7 @@ func (s *ItemService) GetSingleItem(ctx context.Context, tableName string, idStr
 		return nil, err
 	}
 
-	item, err := s.repo.GetByID(ctx, tableName, id)
+	item, err := s.repo.GetByID(ctx, tableName, id, asOf)
 	if err != nil {
 		logrus.Errorf("service: failed to get item by ID from table %s: %v", tableName, err)
 		return nil, fmt.Errorf("failed to get item: %w", err)
@@ -55,11 +105,7 @@ func (s *ItemService) GetSingleItem(ctx context.Context, tableName string, idStr
 }
 
 func (s *ItemService) GetItems(ctx context.Context, tableName string, filter *domains.ItemFilter) ([]map[string]any, int, error) {
-	if err := s.validTableName(tableName); err != nil {
-		return nil, 0, err
-	}
-
-	if err := s.validateAndNormalizeFilter(filter); err != nil {
+	if err := s.prepareList(ctx, tableName, filter, defaultListLimit, maxListLimit); err != nil {
 		return nil, 0, err
 	}
 
@@ -72,8 +118,123 @@ func (s *ItemService) GetItems(ctx context.Context, tableName string, filter *do
 	return items, total, nil
 }
 
+// ExportItems streams the rows matching filter to w. Exports are not paged,
+// they read every row unless the filter has a limit. Exports to a
+// domains.CappedRowWriter read up to ExportMaxRows rows and tell it when more
+// rows matched.
+func (s *ItemService) ExportItems(ctx context.Context, tableName string, filter *domains.ItemFilter, w domains.RowWriter) error {
+	defaultLimit, maxLimit := 0, math.MaxInt
+	if capped, ok := w.(domains.CappedRowWriter); ok {
+		maxRows := s.cfg.GetExportMaxRows()
+		w = capRows(capped, filter, maxRows)
+		// One row more than the cap tells whether the export is truncated.
+		defaultLimit, maxLimit = maxRows+1, maxRows+1
+	}
+	if err := s.prepareList(ctx, tableName, filter, defaultLimit, maxLimit); err != nil {
+		return err
+	}
+
+	if err := s.repo.Stream(ctx, tableName, filter, w); err != nil {
+		logrus.Errorf("service: failed to export items from table %s: %v", tableName, err)
+		return fmt.Errorf("failed to export items: %w", err)
+	}
+	return nil
+}
+
+// capRows caps the rows written to w at maxRows, unless the filter asks for
+// fewer rows.
+func capRows(w domains.CappedRowWriter, filter *domains.ItemFilter, maxRows int) domains.RowWriter {
+	if filter != nil && filter.Limit > 0 && filter.Limit <= maxRows {
+		return w
+	}
+	return &cappedRowWriter{CappedRowWriter: w, maxRows: maxRows}
+}
+
+// cappedRowWriter writes up to maxRows rows and reports any further row as
+// truncation.
+type cappedRowWriter struct {
+	domains.CappedRowWriter
+	maxRows int
+	rows    int
+}
+
+func (w *cappedRowWriter) WriteRow(row map[string]any) error {
+	w.rows++
+	if w.rows > w.maxRows {
+		w.Truncated()
+		return nil
+	}
+	return w.CappedRowWriter.WriteRow(row)
+}
+
+// prepareList checks that filter may be applied to the table and normalizes it.
+func (s *ItemService) prepareList(ctx context.Context, tableName string, filter *domains.ItemFilter, defaultLimit, maxLimit int) error {
+	if err := s.checkTableAccess(ctx, tableName, config.OperationRead); err != nil {
+		return err
+	}
+
+	if err := s.validateAndNormalizeFilter(filter, defaultLimit, maxLimit); err != nil {
+		return err
+	}
+
+	if err := s.checkVersioned(tableName, filter.AsOf != nil); err != nil {
+		return err
+	}
+
+	scope, err := s.repo.TenantScope(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	if scope != nil {
+		if _, filtered := filter.Filters[scope.Column]; filtered {
+			return domains.NewError(domains.ErrCodeInvalidParameter, fmt.Sprintf("filtering on %s is not allowed", scope.Column))
+		}
+	}
+
+	if s.cfg.IsStrict(tableName) {
+		schema, err := s.repo.GetTableSchema(ctx, tableName)
+		if err != nil {
+			return fmt.Errorf("failed to get table schema: %w", err)
+		}
+
+		schema = s.visibleSchema(ctx, tableName, schema)
+		unknownFields := unknownColumns(schema, filter.Filters, "")
+		if filter.OrderBy != "" && !hasColumn(schema, filter.OrderBy) {
+			unknownFields = append(unknownFields, domains.FieldError{Field: "order_by", Message: fmt.Sprintf("unknown column %s", filter.OrderBy)})
+		}
+		if len(unknownFields) > 0 {
+			return &domains.UnknownFieldsError{Fields: unknownFields}
+		}
+	}
+
+	return nil
+}
+
+// GetItemHistory lists the versions of a row of a versioned table.
+func (s *ItemService) GetItemHistory(ctx context.Context, tableName string, idStr string) ([]domains.RowVersion, error) {
+	if err := s.checkTableAccess(ctx, tableName, config.OperationRead); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkVersioned(tableName, true); err != nil {
+		return nil, err
+	}
+
+	id, err := s.convertAndValidateID(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.repo.GetHistory(ctx, tableName, id)
+	if err != nil {
+		logrus.Errorf("service: failed to get history of item in table %s: %v", tableName, err)
+		return nil, fmt.Errorf("failed to get item history: %w", err)
+	}
+	return versions, nil
+}
+
 func (s *ItemService) UpdateItem(ctx context.Context, tableName string, idStr string, req *domains.UpdateItemRequest) (map[string]any, error) {
-	if err := s.validTableName(tableName); err != nil {
+	if err := s.checkTableAccess(ctx, tableName, config.OperationUpdate); err != nil {
 		return nil, err
 	}
 
@@ -86,6 +247,29 @@ func (s *ItemService) UpdateItem(ctx context.Context, tableName string, idStr st
 		return nil, err
 	}
 
+	schema, err := s.repo.GetTableSchema(ctx, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table schema: %w", err)
+	}
+
+	scope, err := s.repo.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.IsStrict(tableName) {
+		if unknownFields := unknownColumns(s.visibleSchema(ctx, tableName, schema), req.Data, "data."); len(unknownFields) > 0 {
+			return nil, &domains.UnknownFieldsError{Fields: unknownFields}
+		}
+	}
+
+	fieldErrors := tenantFieldErrors(scope, req.Data, "data.")
+	fieldErrors = append(fieldErrors, s.checkColumnPolicies(ctx, tableName, req.Data, false, "data.")...)
+	fieldErrors = append(fieldErrors, validateRow(schema, req.Data, false, "data.")...)
+	if len(fieldErrors) > 0 {
+		return nil, &domains.ValidationError{Fields: fieldErrors}
+	}
+
 	item, err := s.repo.Update(ctx, tableName, id, req.Data)
 	if err != nil {
 		logrus.Errorf("service: failed to update item in table %s: %v", tableName, err)
@@ -96,7 +280,7 @@ func (s *ItemService) UpdateItem(ctx context.Context, tableName string, idStr st
 }
 
 func (s *ItemService) DeleteItem(ctx context.Context, tableName string, idStr string) error {
-	if err := s.validTableName(tableName); err != nil {
+	if err := s.checkTableAccess(ctx, tableName, config.OperationDelete); err != nil {
 		return err
 	}
 
@@ -114,19 +298,178 @@ func (s *ItemService) DeleteItem(ctx context.Context, tableName string, idStr st
 	return nil
 }
 
+// checkTableAccess validates the table name and enforces the exposure
+// settings and the scopes of the principal. Tables that are not exposed are
+// reported as missing so that their existence does not leak.
+func (s *ItemService) checkTableAccess(ctx context.Context, tableName, operation string) error {
+	if err := s.validTableName(tableName); err != nil {
+		return err
+	}
+
+	principal := domains.PrincipalFromContext(ctx)
+	if !s.cfg.IsTableExposed(tableName) || !principal.CanAccessTable(tableName) {
+		return domains.NewError(domains.ErrCodeNotFound, fmt.Sprintf("table '%s' not found", tableName))
+	}
+
+	if !s.cfg.IsOperationAllowed(tableName, operation) {
+		return domains.NewError(domains.ErrCodeOperationNotAllowed, fmt.Sprintf("%s is not allowed on table '%s'", operation, tableName))
+	}
+
+	if !principal.CanPerform(operation) {
+		return domains.NewError(domains.ErrCodePermissionDenied, fmt.Sprintf("credentials do not allow %s", operation))
+	}
+
+	return nil
+}
+
+// checkVersioned rejects history reads on tables whose versions are not
+// recorded.
+func (s *ItemService) checkVersioned(tableName string, historyRequested bool) error {
+	if historyRequested && !s.cfg.IsVersioned(tableName) {
+		return domains.NewError(domains.ErrCodeBadRequest, fmt.Sprintf("table '%s' is not versioned", tableName))
+	}
+	return nil
+}
+
+// checkColumnPolicies reports writes to columns whose policy forbids them for
+// the current principal. Writes to hidden columns are reported like writes to
+// columns that do not exist.
+func (s *ItemService) checkColumnPolicies(ctx context.Context, tableName string, data map[string]any, isCreate bool, prefix string) []domains.FieldError {
+	isAdmin := domains.PrincipalFromContext(ctx).HasRole(s.cfg.GetAdminRole())
+
+	columns := make([]string, 0, len(data))
+	for column := range data {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var fieldErrors []domains.FieldError
+	for _, column := range columns {
+		policy := s.cfg.GetColumnPolicy(tableName, column)
+		switch {
+		case policy.Hidden:
+			// Clients are told hidden columns do not exist.
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: prefix + column, Message: "unknown column"})
+		case policy.ReadOnly:
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: prefix + column, Message: "is read-only"})
+		case policy.WriteOnce && !isCreate:
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: prefix + column, Message: "can only be set on create"})
+		case policy.AdminOnly && !isAdmin:
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: prefix + column, Message: "can only be written by administrators"})
+		}
+	}
+	return fieldErrors
+}
+
+// visibleSchema returns a copy of schema without the hidden columns and the
+// constraints involving them.
+func (s *ItemService) visibleSchema(ctx context.Context, tableName string, schema *domains.TableInfo) *domains.TableInfo {
+	isHidden := func(column string) bool {
+		return s.cfg.IsColumnHidden(tableName, column)
+	}
+
+	visible := *schema
+	visible.Columns = nil
+	for _, column := range schema.Columns {
+		if !isHidden(column.Name) {
+			visible.Columns = append(visible.Columns, column)
+		}
+	}
+
+	// References to tables the caller cannot reach, or to their hidden
+	// columns, are dropped too.
+	visible.ForeignKeys = nil
+	for _, foreignKey := range schema.ForeignKeys {
+		isReferenceHidden := func(column string) bool {
+			return s.cfg.IsColumnHidden(foreignKey.ReferencedTable, column)
+		}
+		if slices.ContainsFunc(foreignKey.Columns, isHidden) ||
+			!s.isTableListed(ctx, foreignKey.ReferencedTable) ||
+			slices.ContainsFunc(foreignKey.ReferencedColumns, isReferenceHidden) {
+			continue
+		}
+		visible.ForeignKeys = append(visible.ForeignKeys, foreignKey)
+	}
+
+	visible.CheckConstraints = nil
+	for _, check := range schema.CheckConstraints {
+		if !slices.ContainsFunc(check.Columns, isHidden) {
+			visible.CheckConstraints = append(visible.CheckConstraints, check)
+		}
+	}
+
+	// Expression keys and predicates are SQL text, they are dropped when
+	// they mention a hidden column.
+	mentionsHidden := func(expression string) bool {
+		return slices.ContainsFunc(schema.Columns, func(column domains.DatabaseColumn) bool {
+			return isHidden(column.Name) && strings.Contains(expression, column.Name)
+		})
+	}
+	visible.Indexes = nil
+	for _, index := range schema.Indexes {
+		if slices.ContainsFunc(index.Columns, mentionsHidden) || (index.Predicate != nil && mentionsHidden(*index.Predicate)) {
+			continue
+		}
+		visible.Indexes = append(visible.Indexes, index)
+	}
+	return &visible
+}
+
+// isTableListed reports whether a table is shown to the caller in listings.
+func (s *ItemService) isTableListed(ctx context.Context, tableName string) bool {
+	return tableNamePattern.MatchString(tableName) && s.cfg.IsTableExposed(tableName) && domains.PrincipalFromContext(ctx).CanAccessTable(tableName)
+}
+
+// IsTableReachable reports whether the table exists and is listed for the
+// caller. Existing tables are answered from the schema cache.
+func (s *ItemService) IsTableReachable(ctx context.Context, tableName string) bool {
+	if !s.isTableListed(ctx, tableName) {
+		return false
+	}
+	_, err := s.repo.GetTableSchema(ctx, tableName)
+	return err == nil
+}
+
+// tenantFieldErrors reports client supplied values of the tenant column, the
+// tenant is always taken from the request scope.
+func tenantFieldErrors(scope *domains.TenantScope, data map[string]any, prefix string) []domains.FieldError {
+	if scope == nil {
+		return nil
+	}
+	if _, exists := data[scope.Column]; exists {
+		return []domains.FieldError{{Field: prefix + scope.Column, Message: "is set from the tenant of the request"}}
+	}
+	return nil
+}
+
+// withoutTenantColumn returns schema without the tenant column so that it is
+// not reported as missing on create.
+func withoutTenantColumn(schema *domains.TableInfo, scope *domains.TenantScope) *domains.TableInfo {
+	if scope == nil {
+		return schema
+	}
+
+	scoped := *schema
+	scoped.Columns = nil
+	for _, column := range schema.Columns {
+		if column.Name != scope.Column {
+			scoped.Columns = append(scoped.Columns, column)
+		}
+	}
+	return &scoped
+}
+
 func (s *ItemService) validTableName(tableName string) error {
 	if tableName == "" {
-		return fmt.Errorf("table name cannot be empty")
+		return domains.NewError(domains.ErrCodeInvalidParameter, "table name cannot be empty")
 	}
 
 	if len(tableName) > 63 {
-		return fmt.Errorf("table name too long: maximum 63 character")
+		return domains.NewError(domains.ErrCodeInvalidParameter, "table name too long: maximum 63 character")
 	}
 
-	matched, _ := regexp.MatchString(`^[a-zA-Z_][a-zA-Z0-9_]*$`, tableName)
-
-	if !matched {
-		return fmt.Errorf("invalid table name alphanumeric or underscare is required")
+	if !tableNamePattern.MatchString(tableName) {
+		return domains.NewError(domains.ErrCodeInvalidParameter, "invalid table name alphanumeric or underscare is required")
 	}
 
 	return nil
@@ -134,15 +477,15 @@ func (s *ItemService) validTableName(tableName string) error {
 
 func (s *ItemService) validateCreateRequest(req *domains.CreateItemRequest) error {
 	if req == nil {
-		return fmt.Errorf("request cannot be nil")
+		return domains.NewError(domains.ErrCodeValidationFailed, "request cannot be nil")
 	}
 
 	if req.Data == nil || len(req.Data) == 0 {
-		return fmt.Errorf("data cannot be empty")
+		return domains.NewError(domains.ErrCodeValidationFailed, "data cannot be empty")
 	}
 
 	if len(req.Data) > 100 {
-		return fmt.Errorf("too many fields: maximum 100 fields allowed")
+		return domains.NewError(domains.ErrCodeValidationFailed, "too many fields: maximum 100 fields allowed")
 	}
 
 	return nil
@@ -150,29 +493,29 @@ func (s *ItemService) validateCreateRequest(req *domains.CreateItemRequest) erro
 
 func (s *ItemService) validateUpdateRequest(req *domains.UpdateItemRequest) error {
 	if req == nil {
-		return fmt.Errorf("request cannot be nil")
+		return domains.NewError(domains.ErrCodeValidationFailed, "request cannot be nil")
 	}
 
 	if req.Data == nil || len(req.Data) == 0 {
-		return fmt.Errorf("data cannot be empty")
+		return domains.NewError(domains.ErrCodeValidationFailed, "data cannot be empty")
 	}
 
 	if len(req.Data) > 100 {
-		return fmt.Errorf("too many fields: maximum 100 fields allowed")
+		return domains.NewError(domains.ErrCodeValidationFailed, "too many fields: maximum 100 fields allowed")
 	}
 
 	return nil
 }
 
-func (s *ItemService) validateAndNormalizeFilter(filter *domains.ItemFilter) error {
+func (s *ItemService) validateAndNormalizeFilter(filter *domains.ItemFilter, defaultLimit, maxLimit int) error {
 	if filter == nil {
-		return fmt.Errorf("filter cannot be nil")
+		return domains.NewError(domains.ErrCodeInternal, "filter cannot be nil")
 	}
 	if filter.Limit <= 0 {
-		filter.Limit = 50
+		filter.Limit = defaultLimit
 	}
-	if filter.Limit > 1000 {
-		filter.Limit = 1000 // for preventing performance problems
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
 	}
 
 	if filter.Offset < 0 {
@@ -182,14 +525,14 @@ func (s *ItemService) validateAndNormalizeFilter(filter *domains.ItemFilter) err
 	if filter.OrderBy != "" {
 		matched, _ := regexp.MatchString("^[a-zA-Z_][a-zA-Z0-9_]*$", filter.OrderBy)
 		if !matched {
-			return fmt.Errorf("invalid order by column name")
+			return domains.NewError(domains.ErrCodeInvalidParameter, "invalid order by column name")
 		}
 	}
 
 	if filter.Sort != "" {
 		sort := strings.ToUpper(filter.Sort)
 		if sort != domains.SORT_ASC && sort != domains.SORT_DESC {
-			return fmt.Errorf("invalid sort direction: must be ASC or DESC")
+			return domains.NewError(domains.ErrCodeInvalidParameter, "invalid sort direction: must be ASC or DESC")
 		}
 		filter.Sort = sort
 	}
@@ -199,23 +542,23 @@ func (s *ItemService) validateAndNormalizeFilter(filter *domains.ItemFilter) err
 
 func (s *ItemService) convertAndValidateID(idStr string) (interface{}, error) {
 	if idStr == "" {
-		return nil, fmt.Errorf("ID cannot be empty")
+		return nil, domains.NewError(domains.ErrCodeInvalidParameter, "ID cannot be empty")
 	}
 
 	if intID, err := strconv.ParseInt(idStr, 10, 64); err == nil {
 		if intID <= 0 {
-			return nil, fmt.Errorf("invalid ID: must be positive")
+			return nil, domains.NewError(domains.ErrCodeInvalidParameter, "invalid ID: must be positive")
 		}
 		return intID, nil
 	}
 
 	if len(idStr) > 255 {
-		return nil, fmt.Errorf("ID too long")
+		return nil, domains.NewError(domains.ErrCodeInvalidParameter, "ID too long")
 	}
 
 	matched, _ := regexp.MatchString("^[a-zA-Z0-9_-]+$", idStr)
 	if !matched {
-		return nil, fmt.Errorf("invalid ID format: only alphanumeric characters, underscores, and hyphens allowed")
+		return nil, domains.NewError(domains.ErrCodeInvalidParameter, "invalid ID format: only alphanumeric characters, underscores, and hyphens allowed")
 	}
 
 	return idStr, nil
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"slices"
+	"testing"
+)
+
+type cappedRows struct {
+	rows      []map[string]any
+	truncated bool
+}
+
+func (w *cappedRows) WriteColumns([]domains.DatabaseColumn) error { return nil }
+
+func (w *cappedRows) WriteRow(row map[string]any) error {
+	w.rows = append(w.rows, row)
+	return nil
+}
+
+func (w *cappedRows) Truncated() { w.truncated = true }
+
+func TestCappedRowWriter(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      int
+		written   int
+		truncated bool
+	}{
+		{name: "no rows"},
+		{name: "below the cap", rows: 2, written: 2},
+		{name: "at the cap", rows: 3, written: 3},
+		{name: "over the cap", rows: 4, written: 3, truncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capped := &cappedRows{}
+			w := &cappedRowWriter{CappedRowWriter: capped, maxRows: 3}
+			for i := range tt.rows {
+				if err := w.WriteRow(map[string]any{"id": i}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(capped.rows) != tt.written || capped.truncated != tt.truncated {
+				t.Errorf("written %d, truncated %v, want %d, %v", len(capped.rows), capped.truncated, tt.written, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestCapRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		rows    int
+		written int
+	}{
+		{name: "no limit", rows: 5, written: 3},
+		{name: "limit over the cap", limit: 10, rows: 5, written: 3},
+		{name: "limit at the cap", limit: 3, rows: 3, written: 3},
+		{name: "limit below the cap", limit: 2, rows: 2, written: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capped := &cappedRows{}
+			w := capRows(capped, &domains.ItemFilter{Limit: tt.limit}, 3)
+			for i := range tt.rows {
+				if err := w.WriteRow(map[string]any{"id": i}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(capped.rows) != tt.written || capped.truncated != (tt.rows > tt.written) {
+				t.Errorf("written %d, truncated %v, want %d", len(capped.rows), capped.truncated, tt.written)
+			}
+		})
+	}
+}
+
+func TestVisibleSchemaForeignKeys(t *testing.T) {
+	s := &ItemService{cfg: &config.GenApiConfig{
+		DeniedTables: []string{"accounts"},
+		Tables: map[string]config.TableConfig{
+			"orders": {Columns: map[string]config.ColumnPolicy{"internal_id": {Hidden: true}}},
+			"users":  {Columns: map[string]config.ColumnPolicy{"ssn": {Hidden: true}}},
+		},
+	}}
+	schema := &domains.TableInfo{
+		Columns: []domains.DatabaseColumn{{Name: "id"}, {Name: "user_id"}, {Name: "account_id"}, {Name: "internal_id"}, {Name: "user_ssn"}, {Name: "product_id"}},
+		ForeignKeys: []domains.ForeignKey{
+			{Name: "user", Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+			{Name: "denied table", Columns: []string{"account_id"}, ReferencedTable: "accounts", ReferencedColumns: []string{"id"}},
+			{Name: "hidden column", Columns: []string{"internal_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}},
+			{Name: "hidden referenced column", Columns: []string{"user_ssn"}, ReferencedTable: "users", ReferencedColumns: []string{"ssn"}},
+			{Name: "out of scope", Columns: []string{"product_id"}, ReferencedTable: "products", ReferencedColumns: []string{"id"}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		principal *domains.Principal
+		want      []string
+	}{
+		{name: "anonymous", want: []string{"user", "out of scope"}},
+		{name: "scoped principal", principal: &domains.Principal{AllowedTables: []string{"orders", "users"}}, want: []string{"user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = domains.ContextWithPrincipal(ctx, tt.principal)
+			}
+
+			var got []string
+			for _, foreignKey := range s.visibleSchema(ctx, "orders", schema).ForeignKeys {
+				got = append(got, foreignKey.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("foreign keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckColumnPolicies(t *testing.T) {
+	s := &ItemService{cfg: &config.GenApiConfig{Tables: map[string]config.TableConfig{
+		"users": {Columns: map[string]config.ColumnPolicy{
+			"password_hash": {Hidden: true},
+			"created_at":    {ReadOnly: true},
+			"email":         {WriteOnce: true},
+			"role":          {AdminOnly: true},
+		}},
+	}}}
+	admin := domains.ContextWithPrincipal(context.Background(), &domains.Principal{Role: s.cfg.GetAdminRole()})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		data     map[string]any
+		isCreate bool
+		want     []domains.FieldError
+	}{
+		{name: "no policies", ctx: context.Background(), data: map[string]any{"name": "ann"}, isCreate: true},
+		{name: "hidden column", ctx: context.Background(), data: map[string]any{"password_hash": "x"}, isCreate: true, want: []domains.FieldError{{Field: "data.password_hash", Message: "unknown column"}}},
+		{name: "hidden column written by an administrator", ctx: admin, data: map[string]any{"password_hash": "x"}, want: []domains.FieldError{{Field: "data.password_hash", Message: "unknown column"}}},
+		{name: "read-only column", ctx: context.Background(), data: map[string]any{"created_at": "now"}, isCreate: true, want: []domains.FieldError{{Field: "data.created_at", Message: "is read-only"}}},
+		{name: "write-once column on create", ctx: context.Background(), data: map[string]any{"email": "a@b.c"}, isCreate: true},
+		{name: "write-once column on update", ctx: context.Background(), data: map[string]any{"email": "a@b.c"}, want: []domains.FieldError{{Field: "data.email", Message: "can only be set on create"}}},
+		{name: "admin-only column", ctx: context.Background(), data: map[string]any{"role": "admin"}, want: []domains.FieldError{{Field: "data.role", Message: "can only be written by administrators"}}},
+		{name: "admin-only column written by an administrator", ctx: admin, data: map[string]any{"role": "admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.checkColumnPolicies(tt.ctx, "users", tt.data, tt.isCreate, "data.")
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("checkColumnPolicies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type rowSchemaMode int
+
+const (
+	rowSchemaRead rowSchemaMode = iota
+	rowSchemaCreate
+	rowSchemaUpdate
+)
+
+// rowJSONSchema describes the rows of a table. Read schemas list every
+// column, create schemas require the columns the validator requires, update
+// schemas require none. The primary key and generated columns cannot be
+// written and are left out of write schemas.
+func rowJSONSchema(schema *domains.TableInfo, mode rowSchemaMode, strict bool) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for _, column := range schema.Columns {
+		if mode != rowSchemaRead && (column.Name == schema.PrimaryKey || column.IsGenerated) {
+			continue
+		}
+
+		properties[column.Name] = columnJSONSchema(column)
+
+		switch mode {
+		case rowSchemaRead:
+			required = append(required, column.Name)
+		case rowSchemaCreate:
+			if !column.IsNullable && !column.HasDefault {
+				required = append(required, column.Name)
+			}
+		}
+	}
+
+	rowSchema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		rowSchema["required"] = required
+	}
+	if mode == rowSchemaUpdate {
+		rowSchema["minProperties"] = 1
+	}
+	if strict && mode != rowSchemaRead {
+		rowSchema["additionalProperties"] = false
+	}
+	return rowSchema
+}
+
+// columnJSONSchema describes the values of a column the same way the
+// validator checks them.
+func columnJSONSchema(column domains.DatabaseColumn) map[string]any {
+	schema := typeJSONSchema(column, column.DataType)
+	if column.IsGenerated {
+		schema["readOnly"] = true
+	}
+	if column.IsNullable {
+		schema = nullableJSONSchema(schema)
+	}
+	return schema
+}
+
+func typeJSONSchema(column domains.DatabaseColumn, dataType string) map[string]any {
+	if strings.HasSuffix(dataType, "[]") {
+		elementType := strings.TrimSuffix(dataType, "[]")
+		return map[string]any{
+			"type":  "array",
+			"items": nullableJSONSchema(typeJSONSchema(column, elementType)),
+		}
+	}
+
+	if len(column.EnumValues) > 0 && dataType == column.DataType {
+		values := make([]any, len(column.EnumValues))
+		for i, value := range column.EnumValues {
+			values[i] = value
+		}
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	switch dataType {
+	case "smallint", "int2":
+		return map[string]any{"type": "integer", "minimum": math.MinInt16, "maximum": math.MaxInt16}
+	case "integer", "int4":
+		return map[string]any{"type": "integer", "format": "int32"}
+	case "bigint", "int8":
+		return map[string]any{"type": "integer", "format": "int64"}
+	case "numeric", "decimal", "real", "double precision", "float4", "float8":
+		return map[string]any{"type": "number"}
+	case "boolean", "bool":
+		return map[string]any{"type": "boolean"}
+	case "character varying", "varchar", "character", "bpchar", "text":
+		schema := map[string]any{"type": "string"}
+		if column.MaxLength != nil {
+			schema["maxLength"] = *column.MaxLength
+		}
+		return schema
+	case "uuid":
+		return map[string]any{"type": "string", "format": "uuid"}
+	case "date":
+		return map[string]any{"type": "string", "format": "date"}
+	case "timestamp without time zone", "timestamp with time zone", "timestamp", "timestamptz":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "time without time zone", "time with time zone", "time", "timetz":
+		return map[string]any{"type": "string", "format": "time"}
+	case "json", "jsonb":
+		return map[string]any{}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// nullableJSONSchema additionally allows null.
+func nullableJSONSchema(schema map[string]any) map[string]any {
+	if schemaType, ok := schema["type"].(string); ok {
+		schema["type"] = []any{schemaType, "null"}
+	}
+	if values, ok := schema["enum"].([]any); ok {
+		schema["enum"] = append(values, nil)
+	}
+	return schema
+}
+
+var defaultLiteral = regexp.MustCompile(`^'((?:[^']|'')*)'::[a-z][a-z ]*(?:\[\])?$`)
+
+// literalDefault returns the JSON value of a constant column default such as
+// "'active'::text", "0" or "true". Expressions such as now() or nextval()
+// are not constants and report false.
+func literalDefault(column domains.DatabaseColumn) (any, bool) {
+	if column.DefaultValue == nil {
+		return nil, false
+	}
+	value := stripParentheses(*column.DefaultValue)
+
+	text := value
+	if match := defaultLiteral.FindStringSubmatch(value); match != nil {
+		text = strings.ReplaceAll(match[1], "''", "'")
+	} else if value != "true" && value != "false" {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, false
+		}
+	}
+
+	switch typeJSONSchema(column, column.DataType)["type"] {
+	case "integer", "number":
+		number, err := strconv.ParseFloat(text, 64)
+		return number, err == nil
+	case "boolean":
+		boolean, err := strconv.ParseBool(text)
+		return boolean, err == nil
+	case "string":
+		return text, true
+	}
+	return nil, false
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/sirupsen/logrus"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// MetadataService describes the exposed tables to clients.
+type MetadataService struct {
+	items *ItemService
+	cfg   *config.GenApiConfig
+}
+
+func NewMetadataService(items *ItemService, cfg *config.GenApiConfig) *MetadataService {
+	return &MetadataService{items: items, cfg: cfg}
+}
+
+// ListTables returns the tables the caller can reach with their row
+// estimates. Estimates count every row, so they are left out of tables whose
+// rows are scoped to a tenant or filtered by row-level security.
+func (s *MetadataService) ListTables(ctx context.Context) ([]domains.TableSummary, error) {
+	tables, err := s.items.repo.ListTableSummaries(ctx)
+	if err != nil {
+		logrus.Errorf("service: failed to list tables: %v", err)
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	listed := []domains.TableSummary{}
+	for _, table := range tables {
+		if !s.items.isTableListed(ctx, table.Name) {
+			continue
+		}
+
+		scope, err := s.items.repo.TenantScope(ctx, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		if scope != nil || s.cfg.RowLevelSecurity != nil {
+			table.EstimatedRows = nil
+		}
+		listed = append(listed, table)
+	}
+	return listed, nil
+}
+
+// TableInfo returns the metadata of a table without its hidden columns.
+func (s *MetadataService) TableInfo(ctx context.Context, tableName string) (*domains.TableInfo, error) {
+	if err := s.items.checkTableAccess(ctx, tableName, config.OperationRead); err != nil {
+		return nil, err
+	}
+
+	schema, err := s.items.repo.GetTableSchema(ctx, tableName)
+	if err != nil {
+		logrus.Errorf("service: failed to get schema of table %s: %v", tableName, err)
+		return nil, fmt.Errorf("failed to get table schema: %w", err)
+	}
+	return s.items.visibleSchema(ctx, tableName, schema), nil
+}
+
+// TableSchema returns the schema of a table the caller can reach, without
+// its hidden columns, whatever the operations allowed on it.
+func (s *MetadataService) TableSchema(ctx context.Context, tableName string) (*domains.TableInfo, error) {
+	if err := s.items.validTableName(tableName); err != nil {
+		return nil, err
+	}
+	if !s.items.isTableListed(ctx, tableName) {
+		return nil, domains.NewError(domains.ErrCodeNotFound, fmt.Sprintf("table '%s' not found", tableName))
+	}
+
+	schema, err := s.items.repo.GetTableSchema(ctx, tableName)
+	if err != nil {
+		logrus.Errorf("service: failed to get schema of table %s: %v", tableName, err)
+		return nil, fmt.Errorf("failed to get table schema: %w", err)
+	}
+	return s.items.visibleSchema(ctx, tableName, schema), nil
+}
+
+// TableJSONSchema describes the rows of a table as a JSON Schema document.
+// Required columns are those a create request must provide. Constraints
+// without a JSON Schema equivalent are listed under "x-check-constraints"
+// for reference.
+func (s *MetadataService) TableJSONSchema(ctx context.Context, tableName string) (map[string]any, error) {
+	if err := s.items.checkTableAccess(ctx, tableName, config.OperationRead); err != nil {
+		return nil, err
+	}
+
+	schema, err := s.items.repo.GetTableSchema(ctx, tableName)
+	if err != nil {
+		logrus.Errorf("service: failed to get schema of table %s: %v", tableName, err)
+		return nil, fmt.Errorf("failed to get table schema: %w", err)
+	}
+	scope, err := s.items.repo.TenantScope(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	visible := withoutTenantColumn(s.items.visibleSchema(ctx, tableName, schema), scope)
+
+	document := rowJSONSchema(visible, rowSchemaCreate, s.cfg.IsStrict(tableName))
+	properties := document["properties"].(map[string]any)
+
+	for _, column := range visible.Columns {
+		property, ok := properties[column.Name].(map[string]any)
+		if !ok {
+			property = columnJSONSchema(column)
+			property["readOnly"] = true
+			properties[column.Name] = property
+		}
+		if value, ok := literalDefault(column); ok {
+			property["default"] = value
+		}
+	}
+
+	var untranslated []any
+	for _, check := range visible.CheckConstraints {
+		keywords, ok := translateCheckConstraint(check)
+		property, known := properties[firstOrEmpty(check.Columns)].(map[string]any)
+		if !ok || !known {
+			untranslated = append(untranslated, map[string]any{"name": check.Name, "expression": check.Expression})
+			continue
+		}
+		for keyword, value := range keywords {
+			property[keyword] = value
+		}
+	}
+
+	var foreignKeys []any
+	for _, foreignKey := range visible.ForeignKeys {
+		foreignKeys = append(foreignKeys, map[string]any{
+			"columns":            foreignKey.Columns,
+			"referenced_table":   foreignKey.ReferencedTable,
+			"referenced_columns": foreignKey.ReferencedColumns,
+		})
+		if len(foreignKey.Columns) != 1 {
+			continue
+		}
+		if property, ok := properties[foreignKey.Columns[0]].(map[string]any); ok {
+			property["x-references"] = map[string]any{
+				"table":  foreignKey.ReferencedTable,
+				"column": foreignKey.ReferencedColumns[0],
+			}
+		}
+	}
+
+	document["$schema"] = jsonSchemaDialect
+	document["title"] = tableName
+	if visible.PrimaryKey != "" {
+		document["x-primary-key"] = visible.PrimaryKey
+	}
+	if len(foreignKeys) > 0 {
+		document["x-foreign-keys"] = foreignKeys
+	}
+	if len(untranslated) > 0 {
+		document["x-check-constraints"] = untranslated
+	}
+	return document, nil
+}
+
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/config"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+const openAPIVersion = "3.1.0"
+
+const xlsxMediaType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+type OpenAPIService struct {
+	items *ItemService
+	cfg   *config.GenApiConfig
+}
+
+func NewOpenAPIService(items *ItemService, cfg *config.GenApiConfig) *OpenAPIService {
+	return &OpenAPIService{items: items, cfg: cfg}
+}
+
+// Document builds the OpenAPI document of the tables the caller can reach.
+// serverURL is the base path of the router group serving the API.
+func (s *OpenAPIService) Document(ctx context.Context, serverURL string) (map[string]any, error) {
+	tables, err := s.items.repo.ListTables(ctx)
+	if err != nil {
+		logrus.Errorf("service: failed to list tables for openapi document: %v", err)
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	paths := map[string]any{}
+	schemas := s.componentSchemas()
+	tags := []any{}
+
+	for _, tableName := range tables {
+		if !s.items.isTableListed(ctx, tableName) {
+			continue
+		}
+
+		schema, err := s.items.repo.GetTableSchema(ctx, tableName)
+		if err != nil {
+			logrus.Warnf("service: skipping table %s in openapi document: %v", tableName, err)
+			continue
+		}
+		scope, err := s.items.repo.TenantScope(ctx, tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		visible := s.items.visibleSchema(ctx, tableName, schema)
+		writable := withoutTenantColumn(visible, scope)
+		strict := s.cfg.IsStrict(tableName)
+
+		schemas[tableName] = rowJSONSchema(visible, rowSchemaRead, false)
+		schemas[tableName+".create"] = rowJSONSchema(writable, rowSchemaCreate, strict)
+		schemas[tableName+".update"] = rowJSONSchema(writable, rowSchemaUpdate, strict)
+
+		s.addTablePaths(ctx, paths, tableName, writable)
+		tags = append(tags, map[string]any{"name": tableName})
+	}
+
+	document := map[string]any{
+		"openapi": openAPIVersion,
+		"info":    s.info(),
+		"servers": []any{map[string]any{"url": serverURL}},
+		"tags":    tags,
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas":   schemas,
+			"responses": s.componentResponses(),
+		},
+	}
+
+	if securitySchemes, security := s.security(); len(securitySchemes) > 0 {
+		document["components"].(map[string]any)["securitySchemes"] = securitySchemes
+		document["security"] = security
+	}
+
+	return document, nil
+}
+
+func (s *OpenAPIService) info() map[string]any {
+	info := map[string]any{
+		"title":   s.cfg.OpenAPI.GetTitle(),
+		"version": s.cfg.OpenAPI.GetVersion(),
+	}
+	if s.cfg.OpenAPI.Description != "" {
+		info["description"] = s.cfg.OpenAPI.Description
+	}
+	return info
+}
+
+// addTablePaths documents the item routes of a table, limited to the
+// operations the caller may perform.
+func (s *OpenAPIService) addTablePaths(ctx context.Context, paths map[string]any, tableName string, schema *domains.TableInfo) {
+	principal := domains.PrincipalFromContext(ctx)
+	allowed := func(operation string) bool {
+		return s.cfg.IsOperationAllowed(tableName, operation) && principal.CanPerform(operation)
+	}
+
+	rowRef := schemaRef(tableName)
+	idParameter := map[string]any{
+		"name":        "id",
+		"in":          "path",
+		"required":    true,
+		"description": fmt.Sprintf("Value of the primary key %s", schema.PrimaryKey),
+		"schema":      map[string]any{"type": "string"},
+	}
+
+	collection := map[string]any{}
+	item := map[string]any{}
+
+	if allowed(config.OperationRead) {
+		list := s.operation(tableName, "list", "List rows", s.listParameters(tableName, schema),
+			nil, "200", listResponseSchema(rowRef))
+		addResponseContent(list, "200", "text/csv", map[string]any{"type": "string"})
+		addResponseContent(list, "200", "application/x-ndjson", map[string]any{"type": "string"})
+		addResponseContent(list, "200", xlsxMediaType, map[string]any{"type": "string", "format": "binary"})
+		collection["get"] = list
+
+		parameters := []any{idParameter}
+		if s.cfg.IsVersioned(tableName) {
+			parameters = append(parameters, asOfParameter())
+		}
+		item["get"] = s.operation(tableName, "get", "Get a row by primary key", parameters,
+			nil, "200", dataResponseSchema(rowRef))
+
+		if s.cfg.IsVersioned(tableName) {
+			paths[fmt.Sprintf("/items/%s/{id}/history", tableName)] = map[string]any{
+				"get": s.operation(tableName, "history", "List the versions of a row", []any{idParameter},
+					nil, "200", dataResponseSchema(map[string]any{"type": "array", "items": schemaRef("RowVersion")})),
+			}
+		}
+		if s.cfg.Audit != nil {
+			paths[fmt.Sprintf("/audit/%s/{id}", tableName)] = map[string]any{
+				"get": s.operation(tableName, "audit", "List the audit entries of a row", []any{idParameter},
+					nil, "200", dataResponseSchema(map[string]any{"type": "array", "items": schemaRef("AuditEntry")})),
+			}
+		}
+	}
+
+	if allowed(config.OperationCreate) {
+		body := map[string]any{
+			"type":     "object",
+			"required": []any{"data"},
+			"properties": map[string]any{
+				"data": map[string]any{"type": "array", "minItems": 1, "items": schemaRef(tableName + ".create")},
+			},
+		}
+		collection["post"] = s.operation(tableName, "create", "Create one or more rows", nil, body, "201",
+			map[string]any{"oneOf": []any{dataResponseSchema(rowRef), listResponseSchema(rowRef)}})
+
+		importResult := map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"rows":    map[string]any{"type": "integer"},
+				"dry_run": map[string]any{"type": "boolean"},
+			},
+		}
+		importOperation := s.operation(tableName, "import", "Bulk load rows from CSV, NDJSON or XLSX",
+			[]any{
+				queryParameter("dry_run", "Check the rows without keeping them", map[string]any{"type": "boolean"}),
+				queryParameter("sheet", "XLSX sheet to read, the active sheet by default", map[string]any{"type": "string"}),
+				queryParameter("header_row", "XLSX row holding the column names, counted from 1", map[string]any{"type": "integer", "minimum": 1}),
+			},
+			map[string]any{"type": "string"}, "201", dataResponseSchema(importResult))
+		importOperation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"text/csv":             map[string]any{"schema": map[string]any{"type": "string"}},
+				"application/x-ndjson": map[string]any{"schema": map[string]any{"type": "string"}},
+				xlsxMediaType:          map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}},
+				"multipart/form-data": map[string]any{"schema": map[string]any{
+					"type":       "object",
+					"required":   []any{"file"},
+					"properties": map[string]any{"file": map[string]any{"type": "string", "format": "binary"}},
+				}},
+			},
+		}
+		// Dry runs answer 200 with the same body.
+		responses := importOperation["responses"].(map[string]any)
+		responses["200"] = responses["201"]
+		paths[fmt.Sprintf("/items/%s/import", tableName)] = map[string]any{"post": importOperation}
+	}
+
+	if allowed(config.OperationUpdate) {
+		body := map[string]any{
+			"type":       "object",
+			"required":   []any{"data"},
+			"properties": map[string]any{"data": schemaRef(tableName + ".update")},
+		}
+		item["put"] = s.operation(tableName, "update", "Update a row", []any{idParameter}, body, "200", dataResponseSchema(rowRef))
+	}
+
+	if allowed(config.OperationDelete) {
+		item["delete"] = s.operation(tableName, "delete", "Delete a row", []any{idParameter}, nil, "200", schemaRef("SuccessResponse"))
+	}
+
+	if len(collection) > 0 {
+		paths["/items/"+tableName] = collection
+	}
+	if len(item) > 0 {
+		paths[fmt.Sprintf("/items/%s/{id}", tableName)] = item
+	}
+}
+
+func (s *OpenAPIService) operation(tableName, action, summary string, parameters []any, body map[string]any, status string, response map[string]any) map[string]any {
+	operation := map[string]any{
+		"tags":        []any{tableName},
+		"operationId": action + "_" + tableName,
+		"summary":     summary,
+		"responses":   s.responses(status, response, body != nil),
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if body != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": body}},
+		}
+	}
+	return operation
+}
+
+// addResponseContent documents another media type of a response.
+func addResponseContent(operation map[string]any, status, mediaType string, schema map[string]any) {
+	response := operation["responses"].(map[string]any)[status].(map[string]any)
+	response["content"].(map[string]any)[mediaType] = map[string]any{"schema": schema}
+}
+
+func (s *OpenAPIService) responses(status string, schema map[string]any, isWrite bool) map[string]any {
+	responses := map[string]any{
+		status: map[string]any{
+			"description": "Successful response",
+			"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+		},
+		"400": responseRef("BadRequest"),
+		"403": responseRef("Forbidden"),
+		"404": responseRef("NotFound"),
+		"405": responseRef("MethodNotAllowed"),
+		"500": responseRef("InternalError"),
+	}
+	if isWrite {
+		responses["409"] = responseRef("Conflict")
+		responses["422"] = responseRef("UnprocessableEntity")
+	}
+	if s.cfg.Auth != nil {
+		responses["401"] = responseRef("Unauthorized")
+	}
+	if s.cfg.RateLimit != nil {
+		responses["429"] = responseRef("TooManyRequests")
+	}
+	return responses
+}
+
+// listParameters documents paging, ordering, search and one equality filter
+// per column. Repeating a filter matches any of the given values.
+func (s *OpenAPIService) listParameters(tableName string, schema *domains.TableInfo) []any {
+	columnNames := make([]any, 0, len(schema.Columns))
+	for _, column := range schema.Columns {
+		columnNames = append(columnNames, column.Name)
+	}
+
+	parameters := []any{
+		queryParameter("limit", "Maximum number of rows, 50 by default and at most 1000", map[string]any{"type": "integer", "minimum": 1, "maximum": 1000}),
+		queryParameter("offset", "Number of rows to skip", map[string]any{"type": "integer", "minimum": 0}),
+		queryParameter("order_by", "Column to order by", map[string]any{"type": "string", "enum": columnNames}),
+		queryParameter("sort", "Sort direction", map[string]any{"type": "string", "enum": []any{domains.SORT_ASC, domains.SORT_DESC}}),
+		queryParameter("search", "Case-insensitive substring matched against every column", map[string]any{"type": "string"}),
+		queryParameter("format", fmt.Sprintf("Response format, csv and xlsx export up to %d rows and ndjson every row, ignoring the page size limit", s.cfg.GetExportMaxRows()),
+			map[string]any{"type": "string", "enum": []any{"json", "csv", "ndjson", "xlsx"}}),
+	}
+	if s.cfg.IsVersioned(tableName) {
+		parameters = append(parameters, asOfParameter())
+	}
+
+	for _, column := range schema.Columns {
+		if column.DataType == "json" || column.DataType == "jsonb" || strings.HasSuffix(column.DataType, "[]") {
+			continue
+		}
+		parameter := queryParameter(column.Name, fmt.Sprintf("Filter on %s, repeat to match any of several values", column.Name),
+			map[string]any{"type": "array", "items": typeJSONSchema(column, column.DataType)})
+		parameter["style"] = "form"
+		parameter["explode"] = true
+		parameters = append(parameters, parameter)
+	}
+
+	return parameters
+}
+
+func (s *OpenAPIService) security() (map[string]any, []any) {
+	schemes := map[string]any{}
+	var security []any
+	if s.cfg.Auth == nil {
+		return schemes, security
+	}
+
+	if s.cfg.Auth.JWT != nil || s.cfg.Auth.Authenticator != nil {
+		schemes["bearerAuth"] = map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}
+		security = append(security, map[string]any{"bearerAuth": []any{}})
+	}
+	if s.cfg.Auth.APIKeys != nil {
+		schemes["apiKeyAuth"] = map[string]any{"type": "apiKey", "in": "header", "name": s.cfg.Auth.APIKeys.GetHeader()}
+		security = append(security, map[string]any{"apiKeyAuth": []any{}})
+	}
+	if s.cfg.Auth.AnonymousRole != "" {
+		security = append(security, map[string]any{})
+	}
+	return schemes, security
+}
+
+func (s *OpenAPIService) componentSchemas() map[string]any {
+	stringSchema := map[string]any{"type": "string"}
+	nullableString := map[string]any{"type": []any{"string", "null"}}
+	anyObject := map[string]any{"type": []any{"object", "null"}}
+
+	return map[string]any{
+		"FieldError": map[string]any{
+			"type":     "object",
+			"required": []any{"field", "message"},
+			"properties": map[string]any{
+				"field":   stringSchema,
+				"message": stringSchema,
+			},
+		},
+		"ErrorResponse": map[string]any{
+			"type":     "object",
+			"required": []any{"success", "error"},
+			"properties": map[string]any{
+				"success":    map[string]any{"const": false},
+				"code":       stringSchema,
+				"error":      stringSchema,
+				"message":    stringSchema,
+				"constraint": stringSchema,
+				"column":     stringSchema,
+				"fields":     map[string]any{"type": "array", "items": schemaRef("FieldError")},
+			},
+		},
+		"SuccessResponse": map[string]any{
+			"type":     "object",
+			"required": []any{"success"},
+			"properties": map[string]any{
+				"success": map[string]any{"const": true},
+				"message": stringSchema,
+			},
+		},
+		"RowVersion": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"version_id": map[string]any{"type": "integer", "format": "int64"},
+				"operation":  stringSchema,
+				"valid_from": map[string]any{"type": "string", "format": "date-time"},
+				"valid_to":   map[string]any{"type": []any{"string", "null"}, "format": "date-time"},
+				"data":       map[string]any{"type": "object"},
+			},
+		},
+		"AuditEntry": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"id":          map[string]any{"type": "integer", "format": "int64"},
+				"actor":       nullableString,
+				"table_name":  stringSchema,
+				"primary_key": stringSchema,
+				"operation":   map[string]any{"type": "string", "enum": []any{domains.AuditOperationCreate, domains.AuditOperationUpdate, domains.AuditOperationDelete}},
+				"old_row":     anyObject,
+				"new_row":     anyObject,
+				"request_id":  nullableString,
+				"created_at":  map[string]any{"type": "string", "format": "date-time"},
+			},
+		},
+	}
+}
+
+func (s *OpenAPIService) componentResponses() map[string]any {
+	descriptions := map[string]string{
+		"BadRequest":          "The request is malformed",
+		"Unauthorized":        "Authentication is missing or invalid",
+		"Forbidden":           "The credentials do not allow the operation",
+		"NotFound":            "The table or row does not exist",
+		"MethodNotAllowed":    "The operation is disabled for the table",
+		"Conflict":            "The write violates a constraint",
+		"UnprocessableEntity": "The payload failed validation",
+		"TooManyRequests":     "The rate limit is exceeded",
+		"InternalError":       "The server failed to process the request",
+	}
+
+	responses := make(map[string]any, len(descriptions))
+	for name, description := range descriptions {
+		responses[name] = map[string]any{
+			"description": description,
+			"content":     map[string]any{"application/json": map[string]any{"schema": schemaRef("ErrorResponse")}},
+		}
+	}
+	return responses
+}
+
+func dataResponseSchema(data map[string]any) map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []any{"success", "data"},
+		"properties": map[string]any{
+			"success": map[string]any{"const": true},
+			"data":    data,
+			"message": map[string]any{"type": "string"},
+		},
+	}
+}
+
+func listResponseSchema(row map[string]any) map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []any{"success", "data", "total", "limit", "offset"},
+		"properties": map[string]any{
+			"success": map[string]any{"const": true},
+			"data":    map[string]any{"type": "array", "items": row},
+			"total":   map[string]any{"type": "integer"},
+			"limit":   map[string]any{"type": "integer"},
+			"offset":  map[string]any{"type": "integer"},
+			"message": map[string]any{"type": "string"},
+		},
+	}
+}
+
+func queryParameter(name, description string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"name":        name,
+		"in":          "query",
+		"description": description,
+		"schema":      schema,
+	}
+}
+
+func asOfParameter() map[string]any {
+	return queryParameter("as_of", "Read the state at this time", map[string]any{"type": "string", "format": "date-time"})
+}
+
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func responseRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/responses/" + name}
+}
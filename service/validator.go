@@ -0,0 +1,324 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+var timeLayouts = []string{
+	"15:04:05.999999999Z07:00",
+	"15:04:05Z07:00",
+	"15:04:05.999999999",
+	"15:04:05",
+	"15:04",
+}
+
+// validateRow checks a single payload against the table schema. Unknown columns
+// are skipped, requiredness is only enforced when creating rows.
+func validateRow(schema *domains.TableInfo, data map[string]any, isCreate bool, prefix string) []domains.FieldError {
+	var fieldErrors []domains.FieldError
+
+	for _, column := range schema.Columns {
+		if column.Name == schema.PrimaryKey {
+			continue
+		}
+
+		field := prefix + column.Name
+		value, exists := data[column.Name]
+		if !exists {
+			if isCreate && !column.IsNullable && !column.HasDefault {
+				fieldErrors = append(fieldErrors, domains.FieldError{Field: field, Message: "is required"})
+			}
+			continue
+		}
+
+		if column.IsGenerated {
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: field, Message: "is generated by the database and cannot be written"})
+			continue
+		}
+
+		if value == nil {
+			if !column.IsNullable {
+				fieldErrors = append(fieldErrors, domains.FieldError{Field: field, Message: "cannot be null"})
+			}
+			continue
+		}
+
+		if message := validateColumnValue(column, column.DataType, value); message != "" {
+			fieldErrors = append(fieldErrors, domains.FieldError{Field: field, Message: message})
+		}
+	}
+
+	return fieldErrors
+}
+
+// unknownColumns lists the keys of data that are not columns of the table, in
+// sorted order so that responses are stable.
+func unknownColumns(schema *domains.TableInfo, data map[string]any, prefix string) []domains.FieldError {
+	var unknown []string
+	for key := range data {
+		if !hasColumn(schema, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	fieldErrors := make([]domains.FieldError, 0, len(unknown))
+	for _, key := range unknown {
+		fieldErrors = append(fieldErrors, domains.FieldError{Field: prefix + key, Message: "unknown column"})
+	}
+	return fieldErrors
+}
+
+func hasColumn(schema *domains.TableInfo, name string) bool {
+	for _, column := range schema.Columns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func validateColumnValue(column domains.DatabaseColumn, dataType string, value any) string {
+	if strings.HasSuffix(dataType, "[]") {
+		elements, ok := value.([]any)
+		if !ok {
+			return "must be an array"
+		}
+		elementType := strings.TrimSuffix(dataType, "[]")
+		for i, element := range elements {
+			if element == nil {
+				continue
+			}
+			// Nested lists are the dimensions of multidimensional arrays.
+			elementDataType := elementType
+			if _, nested := element.([]any); nested && elementType != "json" && elementType != "jsonb" {
+				elementDataType = dataType
+			}
+			if message := validateColumnValue(column, elementDataType, element); message != "" {
+				return fmt.Sprintf("element %d %s", i, message)
+			}
+		}
+		return ""
+	}
+
+	if len(column.EnumValues) > 0 && dataType == column.DataType {
+		str, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		for _, allowed := range column.EnumValues {
+			if str == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of: %s", strings.Join(column.EnumValues, ", "))
+	}
+
+	switch dataType {
+	case "smallint", "int2":
+		return validateInteger(value, math.MinInt16, math.MaxInt16)
+	case "integer", "int4":
+		return validateInteger(value, math.MinInt32, math.MaxInt32)
+	case "bigint", "int8":
+		return validateInteger(value, math.MinInt64, math.MaxInt64)
+	case "numeric", "decimal":
+		return validateNumeric(value, column.NumericPrecision, column.NumericScale)
+	case "real", "double precision", "float4", "float8":
+		if _, ok := toFloat(value); !ok {
+			return "must be a number"
+		}
+	case "boolean", "bool":
+		return validateBoolean(value)
+	case "character varying", "varchar", "character", "bpchar", "text":
+		str, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if column.MaxLength != nil && utf8.RuneCountInString(str) > *column.MaxLength {
+			return fmt.Sprintf("must be at most %d characters", *column.MaxLength)
+		}
+	case "uuid":
+		str, ok := value.(string)
+		if !ok || !uuidPattern.MatchString(str) {
+			return "must be a valid UUID"
+		}
+	case "date", "timestamp without time zone", "timestamp with time zone", "timestamp", "timestamptz":
+		// MessagePack decodes its timestamps as time.Time, CBOR ones arrive as
+		// RFC 3339 strings.
+		if _, ok := value.(time.Time); !ok && !matchesLayout(value, dateLayouts) {
+			return "must be a valid date or timestamp"
+		}
+	case "time without time zone", "time with time zone", "time", "timetz":
+		if !matchesLayout(value, timeLayouts) {
+			return "must be a valid time"
+		}
+	}
+
+	return ""
+}
+
+func validateInteger(value any, min, max float64) string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case json.Number:
+		text = v.String()
+	}
+	if text != "" {
+		if _, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err != nil {
+			return "must be an integer"
+		}
+	}
+
+	number, ok := toFloat(value)
+	if !ok {
+		return "must be an integer"
+	}
+	if number != math.Trunc(number) {
+		return "must be an integer"
+	}
+	if number < min || number > max {
+		return fmt.Sprintf("must be between %.0f and %.0f", min, max)
+	}
+	return ""
+}
+
+func validateNumeric(value any, precision, scale *int) string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		// numeric holds values beyond the range of float64.
+		if _, err := strconv.ParseFloat(v, 64); err != nil && !errors.Is(err, strconv.ErrRange) {
+			return "must be a number"
+		}
+		text = v
+	case json.Number:
+		text = v.String()
+	default:
+		number, ok := toFloat(value)
+		if !ok {
+			return "must be a number"
+		}
+		text = strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	if precision == nil {
+		return ""
+	}
+
+	allowedScale := 0
+	if scale != nil {
+		allowedScale = *scale
+	}
+
+	if maxDigits := *precision - allowedScale; integerDigits(text) > maxDigits {
+		return fmt.Sprintf("must have at most %d digits before the decimal point", maxDigits)
+	}
+	return ""
+}
+
+// integerDigits counts the digits before the decimal point of a decimal number,
+// such as 3 for "-0120.5" and for "1.2e2".
+func integerDigits(text string) int {
+	text = strings.TrimLeft(strings.TrimSpace(text), "+-")
+	if strings.EqualFold(text, "NaN") {
+		return 0
+	}
+
+	exponent := 0
+	if e := strings.IndexAny(text, "eE"); e >= 0 {
+		exponent, _ = strconv.Atoi(text[e+1:])
+		text = text[:e]
+	}
+
+	integerPart, fraction, _ := strings.Cut(text, ".")
+	digits := integerPart + fraction
+	point := len(integerPart) + exponent
+	for len(digits) > 0 && digits[0] == '0' {
+		digits = digits[1:]
+		point--
+	}
+	if digits == "" || point < 0 {
+		return 0
+	}
+	return point
+}
+
+func validateBoolean(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return ""
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "t", "true", "y", "yes", "on", "1", "f", "false", "n", "no", "off", "0":
+			return ""
+		}
+	}
+	return "must be a boolean"
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func matchesLayout(value any, layouts []string) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	str = strings.TrimSpace(str)
+	switch strings.ToLower(str) {
+	case "now", "today", "tomorrow", "yesterday", "infinity", "-infinity", "allballs":
+		return true
+	}
+	for _, layout := range layouts {
+		if _, err := time.Parse(layout, str); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateRow(t *testing.T) {
+	maxLength := 5
+	schema := &domains.TableInfo{
+		Name:       "users",
+		PrimaryKey: "id",
+		Columns: []domains.DatabaseColumn{
+			{Name: "id", DataType: "integer"},
+			{Name: "name", DataType: "character varying", MaxLength: &maxLength},
+			{Name: "age", DataType: "integer", IsNullable: true},
+			{Name: "status", DataType: "USER-DEFINED", HasDefault: true, EnumValues: []string{"active", "blocked"}},
+			{Name: "full_name", DataType: "text", IsNullable: true, IsGenerated: true},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		data     map[string]any
+		isCreate bool
+		want     []domains.FieldError
+	}{
+		{name: "valid create", data: map[string]any{"name": "ann", "age": float64(30), "status": "active"}, isCreate: true},
+		{name: "primary key is skipped", data: map[string]any{"id": "not a number", "name": "ann"}, isCreate: true},
+		{name: "unknown columns are skipped", data: map[string]any{"name": "ann", "other": true}, isCreate: true},
+		{name: "missing required column", data: map[string]any{"age": float64(30)}, isCreate: true, want: []domains.FieldError{{Field: "name", Message: "is required"}}},
+		{name: "missing column on update", data: map[string]any{"age": float64(30)}},
+		{name: "null in not null column", data: map[string]any{"name": nil}, want: []domains.FieldError{{Field: "name", Message: "cannot be null"}}},
+		{name: "null in nullable column", data: map[string]any{"age": nil}},
+		{name: "generated column", data: map[string]any{"full_name": nil}, want: []domains.FieldError{{Field: "full_name", Message: "is generated by the database and cannot be written"}}},
+		{
+			name: "invalid values in column order",
+			data: map[string]any{"status": "deleted", "age": 1.5, "name": "annabel"},
+			want: []domains.FieldError{
+				{Field: "name", Message: "must be at most 5 characters"},
+				{Field: "age", Message: "must be an integer"},
+				{Field: "status", Message: "must be one of: active, blocked"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateRow(schema, tt.data, tt.isCreate, "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateRow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	got := validateRow(schema, map[string]any{}, true, "items[2].")
+	want := []domains.FieldError{{Field: "items[2].name", Message: "is required"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validateRow() with prefix = %v, want %v", got, want)
+	}
+}
+
+func TestUnknownColumns(t *testing.T) {
+	schema := &domains.TableInfo{Columns: []domains.DatabaseColumn{{Name: "id"}, {Name: "name"}}}
+
+	got := unknownColumns(schema, map[string]any{"name": "ann", "zeta": 1, "alpha": 2}, "items[0].")
+	want := []domains.FieldError{
+		{Field: "items[0].alpha", Message: "unknown column"},
+		{Field: "items[0].zeta", Message: "unknown column"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unknownColumns() = %v, want %v", got, want)
+	}
+	if got := unknownColumns(schema, map[string]any{"id": 1}, ""); len(got) != 0 {
+		t.Errorf("unknownColumns() = %v, want none", got)
+	}
+}
+
+func TestValidateInteger(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		min   float64
+		max   float64
+		want  string
+	}{
+		{name: "float", value: float64(42), min: math.MinInt32, max: math.MaxInt32},
+		{name: "int", value: 42, min: math.MinInt32, max: math.MaxInt32},
+		{name: "json number", value: json.Number("42"), min: math.MinInt32, max: math.MaxInt32},
+		{name: "string", value: " 42 ", min: math.MinInt32, max: math.MaxInt32},
+		{name: "negative string", value: "-7", min: math.MinInt32, max: math.MaxInt32},
+		{name: "fraction", value: 4.2, min: math.MinInt32, max: math.MaxInt32, want: "must be an integer"},
+		{name: "decimal string", value: "4.0", min: math.MinInt32, max: math.MaxInt32, want: "must be an integer"},
+		{name: "text", value: "abc", min: math.MinInt32, max: math.MaxInt32, want: "must be an integer"},
+		{name: "boolean", value: true, min: math.MinInt32, max: math.MaxInt32, want: "must be an integer"},
+		{name: "upper bound", value: float64(math.MaxInt16), min: math.MinInt16, max: math.MaxInt16},
+		{name: "lower bound", value: float64(math.MinInt16), min: math.MinInt16, max: math.MaxInt16},
+		{name: "too large", value: float64(math.MaxInt16 + 1), min: math.MinInt16, max: math.MaxInt16, want: "must be between -32768 and 32767"},
+		{name: "too small string", value: "-32769", min: math.MinInt16, max: math.MaxInt16, want: "must be between -32768 and 32767"},
+		{name: "bigint string", value: "9223372036854775807", min: math.MinInt64, max: math.MaxInt64},
+		{name: "bigint overflow string", value: "9223372036854775808", min: math.MinInt64, max: math.MaxInt64, want: "must be an integer"},
+		{name: "bigint json number", value: json.Number("9007199254740993"), min: math.MinInt64, max: math.MaxInt64},
+		{name: "bigint overflow json number", value: json.Number("9223372036854775808"), min: math.MinInt64, max: math.MaxInt64, want: "must be an integer"},
+		{name: "fraction json number", value: json.Number("1.5"), min: math.MinInt32, max: math.MaxInt32, want: "must be an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateInteger(tt.value, tt.min, tt.max); got != tt.want {
+				t.Errorf("validateInteger(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNumeric(t *testing.T) {
+	precision, scale, zero := 5, 2, 0
+
+	tests := []struct {
+		name      string
+		value     any
+		precision *int
+		scale     *int
+		want      string
+	}{
+		{name: "unconstrained", value: 123456789.123},
+		{name: "unconstrained string", value: "1e400"},
+		{name: "within precision", value: 999.99, precision: &precision, scale: &scale},
+		{name: "string within precision", value: "-999.999", precision: &precision, scale: &scale},
+		{name: "leading zeros", value: "000999", precision: &precision, scale: &scale},
+		{name: "json number", value: json.Number("12.5"), precision: &precision, scale: &scale},
+		{name: "too many integer digits", value: float64(1000), precision: &precision, scale: &scale, want: "must have at most 3 digits before the decimal point"},
+		{name: "too many integer digits string", value: "+1000.5", precision: &precision, scale: &scale, want: "must have at most 3 digits before the decimal point"},
+		{name: "no scale", value: "123456", precision: &precision, want: "must have at most 5 digits before the decimal point"},
+		{name: "zero scale", value: 12345, precision: &precision, scale: &zero},
+		{name: "exponent", value: "1.2e2", precision: &precision, scale: &scale},
+		{name: "too large exponent", value: "1.2e3", precision: &precision, scale: &scale, want: "must have at most 3 digits before the decimal point"},
+		{name: "negative exponent", value: "12345e-3", precision: &precision, scale: &scale},
+		{name: "beyond float64", value: "1e400", precision: &precision, scale: &scale, want: "must have at most 3 digits before the decimal point"},
+		{name: "text", value: "abc", want: "must be a number"},
+		{name: "boolean", value: false, want: "must be a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateNumeric(tt.value, tt.precision, tt.scale); got != tt.want {
+				t.Errorf("validateNumeric(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBoolean(t *testing.T) {
+	tests := []struct {
+		value any
+		valid bool
+	}{
+		{value: true, valid: true},
+		{value: false, valid: true},
+		{value: "true", valid: true},
+		{value: " Off ", valid: true},
+		{value: "t", valid: true},
+		{value: "0", valid: true},
+		{value: "maybe"},
+		{value: ""},
+		{value: float64(1)},
+		{value: nil},
+	}
+
+	for _, tt := range tests {
+		if got := validateBoolean(tt.value); (got == "") != tt.valid {
+			t.Errorf("validateBoolean(%#v) = %q, valid %v", tt.value, got, tt.valid)
+		}
+	}
+}
+
+func TestValidateColumnValue(t *testing.T) {
+	maxLength := 3
+
+	tests := []struct {
+		name   string
+		column domains.DatabaseColumn
+		value  any
+		want   string
+	}{
+		{name: "integer array", column: domains.DatabaseColumn{DataType: "integer[]"}, value: []any{float64(1), nil, float64(3)}},
+		{name: "not an array", column: domains.DatabaseColumn{DataType: "integer[]"}, value: float64(1), want: "must be an array"},
+		{name: "bigint array of json numbers", column: domains.DatabaseColumn{DataType: "bigint[]"}, value: []any{json.Number("9007199254740993"), nil}},
+		{name: "bigint array overflow", column: domains.DatabaseColumn{DataType: "bigint[]"}, value: []any{json.Number("9223372036854775808")}, want: "element 0 must be an integer"},
+		{name: "invalid element", column: domains.DatabaseColumn{DataType: "integer[]"}, value: []any{float64(1), "x"}, want: "element 1 must be an integer"},
+		{name: "two dimensional array", column: domains.DatabaseColumn{DataType: "integer[]"}, value: []any{[]any{float64(1)}, []any{float64(2)}}},
+		{name: "invalid nested element", column: domains.DatabaseColumn{DataType: "integer[]"}, value: []any{[]any{float64(1), 1.5}}, want: "element 0 element 1 must be an integer"},
+		{name: "jsonb array with lists", column: domains.DatabaseColumn{DataType: "jsonb[]"}, value: []any{[]any{"a", float64(1)}, map[string]any{"b": true}}},
+		{name: "varchar array length", column: domains.DatabaseColumn{DataType: "character varying[]", MaxLength: &maxLength}, value: []any{"abcd"}, want: "element 0 must be at most 3 characters"},
+		{name: "enum", column: domains.DatabaseColumn{DataType: "USER-DEFINED", EnumValues: []string{"a", "b"}}, value: "b"},
+		{name: "enum not allowed", column: domains.DatabaseColumn{DataType: "USER-DEFINED", EnumValues: []string{"a", "b"}}, value: "c", want: "must be one of: a, b"},
+		{name: "enum not a string", column: domains.DatabaseColumn{DataType: "USER-DEFINED", EnumValues: []string{"a"}}, value: float64(1), want: "must be a string"},
+		{name: "enum array", column: domains.DatabaseColumn{DataType: "mood[]", EnumValues: []string{"happy"}}, value: []any{"happy"}},
+		{name: "uuid", column: domains.DatabaseColumn{DataType: "uuid"}, value: "0b1e5a9c-2f6d-4c8e-9a3b-7d4e5f6a7b8c"},
+		{name: "invalid uuid", column: domains.DatabaseColumn{DataType: "uuid"}, value: "0b1e5a9c", want: "must be a valid UUID"},
+		{name: "multibyte length", column: domains.DatabaseColumn{DataType: "text", MaxLength: &maxLength}, value: "äöü"},
+		{name: "double precision", column: domains.DatabaseColumn{DataType: "double precision"}, value: "1.5e3"},
+		{name: "invalid double precision", column: domains.DatabaseColumn{DataType: "real"}, value: true, want: "must be a number"},
+		{name: "unchecked type", column: domains.DatabaseColumn{DataType: "jsonb"}, value: map[string]any{"a": float64(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateColumnValue(tt.column, tt.column.DataType, tt.value); got != tt.want {
+				t.Errorf("validateColumnValue(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateColumnValueTimes(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType string
+		value    any
+		valid    bool
+	}{
+		{name: "rfc3339 timestamp", dataType: "timestamp with time zone", value: "2026-01-02T03:04:05Z", valid: true},
+		{name: "decoded timestamp", dataType: "timestamp with time zone", value: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), valid: true},
+		{name: "decoded date", dataType: "date", value: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), valid: true},
+		{name: "special value", dataType: "timestamp", value: "infinity", valid: true},
+		{name: "invalid timestamp", dataType: "timestamp", value: "yesterday noon"},
+		{name: "number", dataType: "timestamp", value: float64(1767323045)},
+		{name: "time", dataType: "time without time zone", value: "03:04", valid: true},
+		{name: "decoded time of day", dataType: "time without time zone", value: time.Date(0, 1, 1, 3, 4, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := domains.DatabaseColumn{Name: "at", DataType: tt.dataType}
+			message := validateColumnValue(column, tt.dataType, tt.value)
+			if (message == "") != tt.valid {
+				t.Errorf("validateColumnValue() = %q, valid %v", message, tt.valid)
+			}
+		})
+	}
+}
+
+func TestIntegerDigits(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "0", want: 0},
+		{text: "0.001", want: 0},
+		{text: "-0120.5", want: 3},
+		{text: "+7", want: 1},
+		{text: ".5", want: 0},
+		{text: "1.2e2", want: 3},
+		{text: "1.2E-1", want: 0},
+		{text: "0.001e5", want: 3},
+		{text: "0e10", want: 0},
+		{text: "1e400", want: 401},
+		{text: "NaN", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := integerDigits(tt.text); got != tt.want {
+			t.Errorf("integerDigits(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
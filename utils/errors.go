@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"errors"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+var errorStatuses = map[string]int{
+	domains.ErrCodeBadRequest:          http.StatusBadRequest,
+	domains.ErrCodeConflict:            http.StatusConflict,
+	domains.ErrCodeInvalidParameter:    http.StatusBadRequest,
+	domains.ErrCodeUnknownFields:       http.StatusBadRequest,
+	domains.ErrCodeInvalidInput:        http.StatusBadRequest,
+	domains.ErrCodeValidationFailed:    http.StatusUnprocessableEntity,
+	domains.ErrCodeForeignKeyViolation: http.StatusUnprocessableEntity,
+	domains.ErrCodeNotNullViolation:    http.StatusUnprocessableEntity,
+	domains.ErrCodeCheckViolation:      http.StatusUnprocessableEntity,
+	domains.ErrCodeUnauthorized:        http.StatusUnauthorized,
+	domains.ErrCodeNotFound:            http.StatusNotFound,
+	domains.ErrCodeOperationNotAllowed: http.StatusMethodNotAllowed,
+	domains.ErrCodeUniqueViolation:     http.StatusConflict,
+	domains.ErrCodeRowReferenced:       http.StatusConflict,
+	domains.ErrCodePermissionDenied:    http.StatusForbidden,
+	domains.ErrCodeQueryTimeout:        http.StatusGatewayTimeout,
+	domains.ErrCodeRateLimited:         http.StatusTooManyRequests,
+	domains.ErrCodeInternal:            http.StatusInternalServerError,
+}
+
+var errorTitles = map[string]string{
+	domains.ErrCodeInvalidParameter:    "Invalid parameter",
+	domains.ErrCodeUnknownFields:       "Unknown fields",
+	domains.ErrCodeInvalidInput:        "Invalid input syntax",
+	domains.ErrCodeValidationFailed:    "Validation failed",
+	domains.ErrCodeForeignKeyViolation: "Referenced item does not exist",
+	domains.ErrCodeNotNullViolation:    "Required column is missing",
+	domains.ErrCodeCheckViolation:      "Check constraint violated",
+	domains.ErrCodeUnauthorized:        "Unauthorized",
+	domains.ErrCodeNotFound:            "Item not found",
+	domains.ErrCodeOperationNotAllowed: "Operation not allowed",
+	domains.ErrCodeUniqueViolation:     "Item already exists",
+	domains.ErrCodeRowReferenced:       "Item is still referenced",
+	domains.ErrCodePermissionDenied:    "Permission denied",
+	domains.ErrCodeQueryTimeout:        "Query timed out",
+	domains.ErrCodeRateLimited:         "Too many requests",
+}
+
+// StatusForCode returns the HTTP status used for an error code.
+func StatusForCode(code string) int {
+	if status, ok := errorStatuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func codeForStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return domains.ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return domains.ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return domains.ErrCodePermissionDenied
+	case http.StatusNotFound:
+		return domains.ErrCodeNotFound
+	case http.StatusConflict:
+		return domains.ErrCodeConflict
+	case http.StatusUnprocessableEntity:
+		return domains.ErrCodeValidationFailed
+	case http.StatusTooManyRequests:
+		return domains.ErrCodeRateLimited
+	default:
+		return domains.ErrCodeInternal
+	}
+}
+
+// ServiceErrorResponse writes err using the status and code of its
+// classification. Unclassified errors are reported as internal errors with
+// the given fallback message.
+func ServiceErrorResponse(c *gin.Context, err error, fallbackMessage string) {
+	response := ClassifyError(err, fallbackMessage)
+	render(c, StatusForCode(response.Code), response)
+}
+
+// ClassifyError builds the error response of err without writing it.
+func ClassifyError(err error, fallbackMessage string) domains.ErrorResponse {
+	response := domains.ErrorResponse{
+		Success: false,
+		Code:    domains.ErrCodeInternal,
+		Error:   fallbackMessage,
+		Message: err.Error(),
+	}
+
+	var validationErr *domains.ValidationError
+	var unknownFieldsErr *domains.UnknownFieldsError
+	var appErr *domains.Error
+	switch {
+	case errors.As(err, &validationErr):
+		response.Code = domains.ErrCodeValidationFailed
+		response.Fields = validationErr.Fields
+	case errors.As(err, &unknownFieldsErr):
+		response.Code = domains.ErrCodeUnknownFields
+		response.Fields = unknownFieldsErr.Fields
+	case errors.As(err, &appErr):
+		response.Code = appErr.Code
+		response.Constraint = appErr.Constraint
+		response.Column = appErr.Column
+	}
+
+	if title, ok := errorTitles[response.Code]; ok {
+		response.Error = title
+	}
+	return response
+}
@@ -1,18 +1,216 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
 	"net/http"
+	"reflect"
+	"sync"
 )
 
+const (
+	MIMEMsgPack  = "application/msgpack"
+	MIMEXMsgPack = "application/x-msgpack"
+	MIMECBOR     = "application/cbor"
+)
+
+// Codec encodes response bodies and decodes request bodies of a media type.
+// Struct fields are named after their json tags.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// codecRegistry holds the codecs by media type, in the order they were
+// registered, which is the order of preference when the Accept header allows
+// several of them.
+type codecRegistry struct {
+	mu         sync.RWMutex
+	mediaTypes []string
+	codecs     map[string]Codec
+}
+
+var codecs = newCodecRegistry()
+
+func newCodecRegistry() *codecRegistry {
+	registry := &codecRegistry{codecs: make(map[string]Codec)}
+	registry.register(gin.MIMEJSON, jsonCodec{})
+	registry.register(MIMEMsgPack, msgpackCodec{})
+	registry.register(MIMEXMsgPack, msgpackCodec{})
+	registry.register(MIMECBOR, newCBORCodec())
+	return registry
+}
+
+// RegisterCodec makes responses available in mediaType and lets requests send
+// bodies in it. Registering a media type again replaces its codec.
+func RegisterCodec(mediaType string, codec Codec) {
+	codecs.register(mediaType, codec)
+}
+
+func (r *codecRegistry) register(mediaType string, codec Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.codecs[mediaType]; !ok {
+		r.mediaTypes = append(r.mediaTypes, mediaType)
+	}
+	r.codecs[mediaType] = codec
+}
+
+func (r *codecRegistry) lookup(mediaType string) (Codec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	codec, ok := r.codecs[mediaType]
+	return codec, ok
+}
+
+// negotiate picks the codec of a response from the Accept header. JSON is
+// used when the header allows none of the registered media types.
+func (r *codecRegistry) negotiate(c *gin.Context) (string, Codec) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mediaType := c.NegotiateFormat(r.mediaTypes...)
+	if codec, ok := r.codecs[mediaType]; ok {
+		return mediaType, codec
+	}
+	return gin.MIMEJSON, r.codecs[gin.MIMEJSON]
+}
+
+// render writes body with the codec negotiated for the request.
+func render(c *gin.Context, statusCode int, body any) {
+	mediaType, codec := codecs.negotiate(c)
+	if _, ok := codec.(jsonCodec); ok {
+		c.JSON(statusCode, body)
+		return
+	}
+
+	data, err := codec.Marshal(body)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to encode %s response: %w", mediaType, err))
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(statusCode, mediaType, data)
+}
+
+// BindBody decodes the request body into obj with the codec of its
+// Content-Type and validates it like ShouldBindJSON. Bodies of other content
+// types are read as JSON.
+func BindBody(c *gin.Context, obj any) error {
+	codec, ok := codecs.lookup(c.ContentType())
+	if !ok {
+		codec = jsonCodec{}
+	}
+	if c.Request == nil || c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	if err := codec.Unmarshal(data, obj); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// jsonCodec is rendered by gin, it is registered so that JSON takes part in
+// the negotiation and can be replaced.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes numbers as json.Number, so that integers beyond 2^53 and
+// numeric values keep their exact digits.
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// msgpackCodec encodes MessagePack. Integers are decoded as int64 or uint64
+// and floats as float64, like JSON numbers they do not depend on the size
+// chosen by the sender.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	decoder.UseLooseInterfaceDecoding(true)
+	return decoder.Decode(v)
+}
+
+// cborCodec encodes CBOR. Times are written as RFC 3339 strings, tagged
+// times are decoded as RFC 3339 strings and maps are decoded with string keys,
+// as they are from JSON.
+type cborCodec struct {
+	enc cbor.EncMode
+	dec cbor.DecMode
+}
+
+var (
+	cborEncMode = mustCBORMode(cbor.EncOptions{Time: cbor.TimeRFC3339Nano, TimeTag: cbor.EncTagRequired}.EncMode())
+	cborDecMode = mustCBORMode(cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]any(nil)),
+		TimeTagToAny:   cbor.TimeTagToRFC3339Nano,
+	}.DecMode())
+)
+
+// mustCBORMode returns mode or panics on err. The CBOR options are constant,
+// so an error is a programming mistake that shows when the package loads.
+func mustCBORMode[T any](mode T, err error) T {
+	if err != nil {
+		panic(fmt.Sprintf("invalid CBOR options: %v", err))
+	}
+	return mode
+}
+
+func newCBORCodec() cborCodec {
+	return cborCodec{enc: cborEncMode, dec: cborDecMode}
+}
+
+func (c cborCodec) Marshal(v any) ([]byte, error) {
+	return c.enc.Marshal(v)
+}
+
+func (c cborCodec) Unmarshal(data []byte, v any) error {
+	return c.dec.Unmarshal(data, v)
+}
+
 func SuccessResponse(c *gin.Context, data any, message string) {
 	response := domains.ItemResponse{
 		Success: true,
 		Data:    data.(map[string]any),
 		Message: message,
 	}
-	c.JSON(http.StatusOK, response)
+	render(c, http.StatusOK, response)
 }
 
 func CreatedResponse(c *gin.Context, data any, message string) {
@@ -21,7 +219,7 @@ func CreatedResponse(c *gin.Context, data any, message string) {
 		Data:    data.(map[string]any),
 		Message: message,
 	}
-	c.JSON(http.StatusCreated, response)
+	render(c, http.StatusCreated, response)
 }
 
 func ListResponse(c *gin.Context, data []map[string]any, total, limit, offset int, message string) {
@@ -33,12 +231,22 @@ func ListResponse(c *gin.Context, data []map[string]any, total, limit, offset in
 		Offset:  offset,
 		Message: message,
 	}
-	c.JSON(http.StatusOK, response)
+	render(c, http.StatusOK, response)
+}
+
+func DataResponse(c *gin.Context, statusCode int, data any, message string) {
+	response := domains.DataResponse{
+		Success: true,
+		Data:    data,
+		Message: message,
+	}
+	render(c, statusCode, response)
 }
 
 func ErrorResponse(c *gin.Context, statusCode int, message string, err error) {
 	response := domains.ErrorResponse{
 		Success: false,
+		Code:    codeForStatus(statusCode),
 		Error:   message,
 	}
 
@@ -46,13 +254,21 @@ func ErrorResponse(c *gin.Context, statusCode int, message string, err error) {
 		response.Message = err.Error()
 	}
 
-	c.JSON(statusCode, response)
+	render(c, statusCode, response)
 }
 
 func BadRequestResponse(c *gin.Context, message string, err error) {
 	ErrorResponse(c, http.StatusBadRequest, message, err)
 }
 
+func UnauthorizedResponse(c *gin.Context, message string, err error) {
+	ErrorResponse(c, http.StatusUnauthorized, message, err)
+}
+
+func ForbiddenResponse(c *gin.Context, message string, err error) {
+	ErrorResponse(c, http.StatusForbidden, message, err)
+}
+
 func NotFoundResponse(c *gin.Context, message string, err error) {
 	ErrorResponse(c, http.StatusNotFound, message, err)
 }
@@ -69,10 +285,14 @@ func ConflictResponse(c *gin.Context, message string, err error) {
 	ErrorResponse(c, http.StatusConflict, message, err)
 }
 
+func TooManyRequestsResponse(c *gin.Context, message string, err error) {
+	ErrorResponse(c, http.StatusTooManyRequests, message, err)
+}
+
 func DeletedResponse(c *gin.Context, message string) {
 	response := domains.ItemResponse{
 		Success: true,
 		Message: message,
 	}
-	c.JSON(http.StatusOK, response)
+	render(c, http.StatusOK, response)
 }
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"encoding/json"
+	"github.com/abdulaziz-go/go-gen-apis/domains"
+	"github.com/fxamacker/cbor/v2"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	tests := []struct {
+		name  string
+		codec Codec
+		value any
+		want  any
+	}{
+		{name: "json", codec: jsonCodec{}, value: map[string]any{"id": 1, "name": "a"}, want: map[string]any{"id": json.Number("1"), "name": "a"}},
+		{name: "msgpack", codec: msgpackCodec{}, value: map[string]any{"id": 1, "name": "a"}, want: map[string]any{"id": int64(1), "name": "a"}},
+		{name: "msgpack nested", codec: msgpackCodec{}, value: map[string]any{"tags": []any{"a", nil}}, want: map[string]any{"tags": []any{"a", nil}}},
+		{name: "cbor", codec: newCBORCodec(), value: map[string]any{"id": 1, "name": "a"}, want: map[string]any{"id": uint64(1), "name": "a"}},
+		{name: "cbor negative", codec: newCBORCodec(), value: map[string]any{"id": -1}, want: map[string]any{"id": int64(-1)}},
+		{name: "cbor time", codec: newCBORCodec(), value: map[string]any{"created_at": created}, want: map[string]any{"created_at": "2026-01-02T03:04:05.000006Z"}},
+		{name: "cbor nested map", codec: newCBORCodec(), value: map[string]any{"meta": map[string]any{"a": true}}, want: map[string]any{"meta": map[string]any{"a": true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.codec.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal() = %v", err)
+			}
+
+			var got map[string]any
+			if err := tt.codec.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("round trip = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCBORDecodesTimeTags(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		tag  any
+		want string
+	}{
+		{name: "epoch tag", tag: cbor.Tag{Number: 1, Content: created.Unix()}, want: "2026-01-02T03:04:05Z"},
+		{name: "string tag", tag: cbor.Tag{Number: 0, Content: "2026-01-02T03:04:05Z"}, want: "2026-01-02T03:04:05Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := cbor.Marshal(map[string]any{"created_at": tt.tag})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got map[string]any
+			if err := newCBORCodec().Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() = %v", err)
+			}
+			if got["created_at"] != tt.want {
+				t.Errorf("created_at = %#v, want %q", got["created_at"], tt.want)
+			}
+		})
+	}
+}
+
+func TestBindBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        map[string]any
+		wantErr     bool
+	}{
+		{
+			name:        "integer beyond 2^53",
+			contentType: "application/json",
+			body:        `{"data": {"id": 9007199254740993, "amount": 12345678901234567890.123}}`,
+			want:        map[string]any{"id": json.Number("9007199254740993"), "amount": json.Number("12345678901234567890.123")},
+		},
+		{
+			name:        "other content type",
+			contentType: "text/plain",
+			body:        `{"data": {"id": 9007199254740993}}`,
+			want:        map[string]any{"id": json.Number("9007199254740993")},
+		},
+		{
+			name:        "bigint array",
+			contentType: "application/json",
+			body:        `{"data": {"ids": [9007199254740993, null, -9007199254740993]}}`,
+			want:        map[string]any{"ids": []any{json.Number("9007199254740993"), nil, json.Number("-9007199254740993")}},
+		},
+		{name: "missing data", contentType: "application/json", body: `{}`, wantErr: true},
+		{name: "trailing data", contentType: "application/json", body: `{"data": {}} {}`, wantErr: true},
+		{name: "empty body", contentType: "application/json", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("PUT", "/items/users/1", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+
+			var req domains.UpdateItemRequest
+			err := BindBody(c, &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BindBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(req.Data, tt.want) {
+				t.Errorf("BindBody() data = %#v, want %#v", req.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindBodyRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"data":{"id":9007199254740993}}`
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("PUT", "/items/users/1", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var req domains.UpdateItemRequest
+	if err := BindBody(c, &req); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := jsonCodec{}.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != body {
+		t.Errorf("round trip = %s, want %s", encoded, body)
+	}
+}